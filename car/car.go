@@ -0,0 +1,200 @@
+// Package car reads and writes CAR (Content Addressable aRchive) files,
+// which package a set of blocks -- typically a DAG, or part of one --
+// into a single stream of bytes.
+//
+// A CARv1 stream is a header naming the roots of the archive, followed by
+// the blocks, each with the CID it's addressed by.  Every part is prefixed
+// with its length as an unsigned varint:
+//
+//	varint | header (dag-cbor: {"roots": [CID...], "version": 1})
+//	varint | CID bytes | block bytes
+//	varint | CID bytes | block bytes
+//	...
+//
+// See https://github.com/ipld/specs/blob/master/block-layer/content-addressable-archives.md
+// for the specification.
+//
+// CARv2 files, which wrap a CARv1 stream together with an index of its blocks
+// for random access, are read by OpenV2 and written by WriteV2.
+//
+// Since CAR files are all about CIDs, this package works with cid.Cid and
+// cidlink.Link rather than the more general ipld.Link.
+package car
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	cid "github.com/ipfs/go-cid"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/fluent"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+// Header is the first part of a CARv1 stream.
+type Header struct {
+	Roots   []cid.Cid
+	Version uint64 // Always 1, for CARv1.
+}
+
+// MaxSectionSize is the largest header or section (CID plus block) that will
+// be read.  Anything larger is rejected as invalid, rather than read.
+// (Blocks are rarely more than a couple of megabytes, in practice.)
+var MaxSectionSize uint64 = 32 << 20
+
+func writeHeader(w io.Writer, h Header) error {
+	var n ipld.Node
+	err := fluent.Recover(func() {
+		// Keys are in canonical dag-cbor order (shortest first).
+		n = fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+			na.AssembleEntry("roots").CreateList(len(h.Roots), func(na fluent.ListAssembler) {
+				for _, c := range h.Roots {
+					na.AssembleValue().AssignLink(cidlink.Link{Cid: c})
+				}
+			})
+			na.AssembleEntry("version").AssignInt(int(h.Version))
+		})
+	})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := dagcbor.Encoder(n, &buf); err != nil {
+		return err
+	}
+	return writeSection(w, buf.Bytes())
+}
+
+func readHeader(r *countingReader) (Header, error) {
+	data, err := readSection(r)
+	if err == io.EOF {
+		return Header{}, fmt.Errorf("invalid car header: %s", io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return Header{}, fmt.Errorf("invalid car header: %s", err)
+	}
+	nb := basicnode.Style__Map{}.NewBuilder()
+	if err := dagcbor.Decoder(nb, bytes.NewReader(data)); err != nil {
+		return Header{}, fmt.Errorf("invalid car header: %s", err)
+	}
+	n := nb.Build()
+	var h Header
+	versionNode, err := n.LookupString("version")
+	if err != nil {
+		return Header{}, fmt.Errorf("invalid car header: %s", err)
+	}
+	version, err := versionNode.AsInt()
+	if err != nil {
+		return Header{}, fmt.Errorf("invalid car header: version must be an int")
+	}
+	h.Version = uint64(version)
+	if h.Version != 1 {
+		// Other versions may not even have roots.
+		return h, ErrUnsupportedVersion{h.Version}
+	}
+	rootsNode, err := n.LookupString("roots")
+	if err != nil {
+		return Header{}, fmt.Errorf("invalid car header: %s", err)
+	}
+	if rootsNode.ReprKind() != ipld.ReprKind_List {
+		return Header{}, fmt.Errorf("invalid car header: roots must be a list")
+	}
+	for itr := rootsNode.ListIterator(); !itr.Done(); {
+		_, v, err := itr.Next()
+		if err != nil {
+			return Header{}, fmt.Errorf("invalid car header: %s", err)
+		}
+		lnk, err := v.AsLink()
+		if err != nil {
+			return Header{}, fmt.Errorf("invalid car header: roots must be links")
+		}
+		h.Roots = append(h.Roots, lnk.(cidlink.Link).Cid)
+	}
+	return h, nil
+}
+
+// ErrUnsupportedVersion is returned when reading a CAR stream with a version
+// this package doesn't know how to read.
+type ErrUnsupportedVersion struct {
+	Version uint64
+}
+
+func (e ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("unsupported car version %d", e.Version)
+}
+
+// writeSection writes data, prefixed with its length.
+func writeSection(w io.Writer, data ...[]byte) error {
+	var size int
+	for _, d := range data {
+		size += len(d)
+	}
+	var prefix [binary.MaxVarintLen64]byte
+	if _, err := w.Write(prefix[:binary.PutUvarint(prefix[:], uint64(size))]); err != nil {
+		return err
+	}
+	for _, d := range data {
+		if _, err := w.Write(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSection reads a length-prefixed section.
+// It returns io.EOF only if the stream ended cleanly before the section.
+func readSection(r *countingReader) ([]byte, error) {
+	start := r.n
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		if err == io.EOF && r.n > start {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if size > MaxSectionSize {
+		return nil, fmt.Errorf("section length %d exceeds the maximum of %d", size, MaxSectionSize)
+	}
+	// Read through a LimitReader, rather than allocating the declared size
+	// up front; a truncated stream can then only cost as much as it contains.
+	data, err := ioutil.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
+
+// countingReader keeps track of the offset in a stream, so that errors
+// (and indexes) can refer to it.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func newCountingReader(r io.Reader) *countingReader {
+	return &countingReader{r: bufio.NewReader(r)}
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *countingReader) ReadByte() (byte, error) {
+	b, err := cr.r.ReadByte()
+	if err == nil {
+		cr.n++
+	}
+	return b, err
+}
@@ -0,0 +1,196 @@
+package car_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	cid "github.com/ipfs/go-cid"
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/car"
+	_ "github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/fluent"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+	"github.com/ipld/go-ipld-prime/traversal"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+	"github.com/ipld/go-ipld-prime/traversal/selector/builder"
+)
+
+// fixture is a little DAG: a root map, linking to a list and a string,
+// the list in turn linking to that same string and to another.
+type fixture struct {
+	storage                 map[cid.Cid][]byte
+	root, list, alpha, beta cid.Cid
+}
+
+func newFixture(t *testing.T) fixture {
+	f := fixture{storage: make(map[cid.Cid][]byte)}
+	encode := func(n ipld.Node) cid.Cid {
+		lb := cidlink.LinkBuilder{Prefix: cid.Prefix{
+			Version:  1,
+			Codec:    0x71,
+			MhType:   0x12,
+			MhLength: 32,
+		}}
+		var buf bytes.Buffer
+		lnk, err := lb.Build(context.Background(), ipld.LinkContext{}, n,
+			func(ipld.LinkContext) (io.Writer, ipld.StoreCommitter, error) {
+				return &buf, func(lnk ipld.Link) error {
+					f.storage[lnk.(cidlink.Link).Cid] = buf.Bytes()
+					return nil
+				}, nil
+			},
+		)
+		Require(t, err, ShouldEqual, nil)
+		return lnk.(cidlink.Link).Cid
+	}
+	f.alpha = encode(basicnode.NewString("alpha"))
+	f.beta = encode(basicnode.NewString("beta"))
+	f.list = encode(fluent.MustBuildList(basicnode.Style__List{}, 2, func(na fluent.ListAssembler) {
+		na.AssembleValue().AssignLink(cidlink.Link{Cid: f.alpha})
+		na.AssembleValue().AssignLink(cidlink.Link{Cid: f.beta})
+	}))
+	f.root = encode(fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+		na.AssembleEntry("string").AssignLink(cidlink.Link{Cid: f.alpha})
+		na.AssembleEntry("list").AssignLink(cidlink.Link{Cid: f.list})
+	}))
+	return f
+}
+
+// otherLink is an ipld.Link which isn't a cidlink.Link.
+type otherLink struct{ ipld.Link }
+
+func (f fixture) prog() traversal.Progress {
+	return traversal.Progress{Cfg: &traversal.Config{
+		LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+			return bytes.NewReader(f.storage[lnk.(cidlink.Link).Cid]), nil
+		},
+		LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+			return basicnode.Style__Any{}, nil
+		},
+	}}
+}
+
+// readAll returns the roots and the CIDs of the blocks in a CAR stream, in order.
+func readAll(t *testing.T, data []byte) ([]cid.Cid, []cid.Cid) {
+	cr, err := car.NewReader(bytes.NewReader(data))
+	Require(t, err, ShouldEqual, nil)
+	Wish(t, cr.Header.Version, ShouldEqual, uint64(1))
+	var cids []cid.Cid
+	for {
+		c, _, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		Require(t, err, ShouldEqual, nil)
+		cids = append(cids, c)
+	}
+	return cr.Header.Roots, cids
+}
+
+func TestCar(t *testing.T) {
+	f := newFixture(t)
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	sAll, err := ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+		ssb.Matcher(),
+		ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+	)).Selector()
+	Require(t, err, ShouldEqual, nil)
+
+	t.Run("header should be encoded as dag-cbor", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := car.NewWriter(&buf, nil)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, hex.EncodeToString(buf.Bytes()), ShouldEqual, "11"+"a265726f6f747380"+"6776657273696f6e01")
+	})
+	t.Run("blocks written should be read back", func(t *testing.T) {
+		var buf bytes.Buffer
+		cw, err := car.NewWriter(&buf, []cid.Cid{f.root})
+		Require(t, err, ShouldEqual, nil)
+		for _, c := range []cid.Cid{f.root, f.list, f.alpha, f.list, f.beta} {
+			Require(t, cw.WriteBlock(c, f.storage[c]), ShouldEqual, nil)
+		}
+		roots, cids := readAll(t, buf.Bytes())
+		Wish(t, roots, ShouldEqual, []cid.Cid{f.root})
+		Wish(t, cids, ShouldEqual, []cid.Cid{f.root, f.list, f.alpha, f.beta})
+
+		cr, err := car.NewReader(bytes.NewReader(buf.Bytes()))
+		Require(t, err, ShouldEqual, nil)
+		loader, err := cr.Loader()
+		Require(t, err, ShouldEqual, nil)
+		nb := basicnode.Style__Any{}.NewBuilder()
+		err = cidlink.Link{Cid: f.root}.Load(context.Background(), ipld.LinkContext{}, nb, loader)
+		Require(t, err, ShouldEqual, nil)
+		prog := f.prog()
+		prog.Cfg.LinkLoader = loader
+		var visits int
+		err = prog.WalkMatching(nb.Build(), sAll, func(traversal.Progress, ipld.Node) error {
+			visits++
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, visits, ShouldEqual, 5)
+	})
+	t.Run("selective write should include only covered blocks", func(t *testing.T) {
+		s, err := ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+			efsb.Insert("list", ssb.ExploreIndex(1, ssb.Matcher()))
+		}).Selector()
+		Require(t, err, ShouldEqual, nil)
+		var buf bytes.Buffer
+		Require(t, car.WriteSelective(&buf, f.prog(), f.root, s), ShouldEqual, nil)
+		roots, cids := readAll(t, buf.Bytes())
+		Wish(t, roots, ShouldEqual, []cid.Cid{f.root})
+		Wish(t, cids, ShouldEqual, []cid.Cid{f.root, f.list, f.beta})
+	})
+	t.Run("selective write of everything should include each block once", func(t *testing.T) {
+		var buf bytes.Buffer
+		Require(t, car.WriteSelective(&buf, f.prog(), f.root, sAll), ShouldEqual, nil)
+		_, cids := readAll(t, buf.Bytes())
+		Wish(t, cids, ShouldEqual, []cid.Cid{f.root, f.alpha, f.list, f.beta})
+	})
+	t.Run("storer should reject links other than cidlink", func(t *testing.T) {
+		cw, err := car.NewWriter(&bytes.Buffer{}, nil)
+		Require(t, err, ShouldEqual, nil)
+		_, commit, err := cw.Storer()(ipld.LinkContext{})
+		Require(t, err, ShouldEqual, nil)
+		err = commit(otherLink{cidlink.Link{Cid: f.root}})
+		Wish(t, err.Error(), ShouldEqual, "car writer can only store cidlink.Link, not car_test.otherLink")
+	})
+	t.Run("selective write should not write blocks which don't match their CID", func(t *testing.T) {
+		prog := f.prog()
+		prog.Cfg.LinkLoader = func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+			if lnk.(cidlink.Link).Cid == f.list {
+				return bytes.NewReader(f.storage[f.alpha]), nil
+			}
+			return bytes.NewReader(f.storage[lnk.(cidlink.Link).Cid]), nil
+		}
+		var buf bytes.Buffer
+		err := car.WriteSelective(&buf, prog, f.root, sAll)
+		Wish(t, err != nil, ShouldEqual, true)
+		_, cids := readAll(t, buf.Bytes())
+		Wish(t, cids, ShouldEqual, []cid.Cid{f.root, f.alpha})
+	})
+	t.Run("selective write should count the root against the budgets", func(t *testing.T) {
+		prog := f.prog()
+		prog.Cfg.MaxLinks = 4 // the walk loads alpha twice, so there are five loads with the root.
+		err := car.WriteSelective(&bytes.Buffer{}, prog, f.root, sAll)
+		Wish(t, err, ShouldEqual, traversal.ErrBudgetExceeded{Budget: traversal.BudgetKind_Links, Limit: 4, Path: ipld.NewPath([]ipld.PathSegment{ipld.PathSegmentOfString("list"), ipld.PathSegmentOfInt(1)})})
+		prog.Cfg.MaxLinks = 5
+		Wish(t, car.WriteSelective(&bytes.Buffer{}, prog, f.root, sAll), ShouldEqual, nil)
+	})
+	t.Run("truncated streams should be rejected", func(t *testing.T) {
+		var buf bytes.Buffer
+		Require(t, car.WriteSelective(&buf, f.prog(), f.root, sAll), ShouldEqual, nil)
+		cr, err := car.NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-3]))
+		Require(t, err, ShouldEqual, nil)
+		_, err = cr.Loader()
+		Wish(t, err != nil, ShouldEqual, true)
+		_, err = car.NewReader(bytes.NewReader(buf.Bytes()[:5]))
+		Wish(t, err.Error(), ShouldEqual, "invalid car header: unexpected EOF")
+	})
+}
@@ -0,0 +1,206 @@
+package car
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// IndexCodec is the multicodec code of the index format used in CARv2 files
+// written by this package ("car-multihash-index-sorted").
+// No other format is supported for reading, either.
+const IndexCodec = 0x0401
+
+// Index maps the multihashes of the blocks in a CARv1 stream to the offsets
+// of their sections in that stream (the offset of the section's length prefix,
+// counting from the start of the stream).
+//
+// It's keyed by multihash, rather than by CID, as in the CARv2 specification:
+// so blocks which are addressed by several CIDs (differing only in codec,
+// for example) are indexed once.
+type Index struct {
+	offsets map[string]uint64 // keys are multihashes, in their binary form.
+}
+
+// BuildIndex reads a CARv1 stream to the end, and returns an index of its blocks.
+// (Where a block appears more than once, the first appearance is indexed.)
+func BuildIndex(r io.Reader) (Index, error) {
+	cr, err := NewReader(r)
+	if err != nil {
+		return Index{}, err
+	}
+	idx := Index{make(map[string]uint64)}
+	for {
+		offset, c, _, err := cr.next()
+		if err == io.EOF {
+			return idx, nil
+		}
+		if err != nil {
+			return Index{}, err
+		}
+		if _, exists := idx.offsets[string(c.Hash())]; !exists {
+			idx.offsets[string(c.Hash())] = uint64(offset)
+		}
+	}
+}
+
+// Offset returns the offset of the section containing the block with the
+// same multihash as the given CID, if there is one.
+func (idx Index) Offset(c cid.Cid) (uint64, bool) {
+	offset, ok := idx.offsets[string(c.Hash())]
+	return offset, ok
+}
+
+// Len returns the number of blocks in the index.
+func (idx Index) Len() int {
+	return len(idx.offsets)
+}
+
+// The serial form of the index is as follows (all integers are little-endian):
+//
+//	varint | IndexCodec
+//	int32  | count of multihash codes
+//	  (for each code, ascending:)
+//	  uint64 | multihash code
+//	  int32  | count of digest widths
+//	    (for each width, ascending:)
+//	    uint32 | width of each entry (the digest width, plus 8)
+//	    int64  | total length of the entries, in bytes
+//	    entries, sorted by digest: digest | uint64 offset
+
+// WriteTo writes the index in its serial form (as found at the end of a CARv2 file).
+func (idx Index) WriteTo(w io.Writer) (int64, error) {
+	// Group by code, then by width.
+	groups := make(map[uint64]map[uint32][][]byte)
+	for mh, offset := range idx.offsets {
+		code, digest, err := splitMultihash([]byte(mh))
+		if err != nil {
+			return 0, err
+		}
+		entry := make([]byte, len(digest)+8)
+		copy(entry, digest)
+		binary.LittleEndian.PutUint64(entry[len(digest):], offset)
+		if groups[code] == nil {
+			groups[code] = make(map[uint32][][]byte)
+		}
+		groups[code][uint32(len(entry))] = append(groups[code][uint32(len(entry))], entry)
+	}
+
+	var buf bytes.Buffer
+	var prefix [binary.MaxVarintLen64]byte
+	buf.Write(prefix[:binary.PutUvarint(prefix[:], IndexCodec)])
+	codes := make([]uint64, 0, len(groups))
+	for code := range groups {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	binary.Write(&buf, binary.LittleEndian, int32(len(codes)))
+	for _, code := range codes {
+		binary.Write(&buf, binary.LittleEndian, code)
+		widths := make([]uint32, 0, len(groups[code]))
+		for width := range groups[code] {
+			widths = append(widths, width)
+		}
+		sort.Slice(widths, func(i, j int) bool { return widths[i] < widths[j] })
+		binary.Write(&buf, binary.LittleEndian, int32(len(widths)))
+		for _, width := range widths {
+			entries := groups[code][width]
+			sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i], entries[j]) < 0 })
+			binary.Write(&buf, binary.LittleEndian, width)
+			binary.Write(&buf, binary.LittleEndian, int64(len(entries))*int64(width))
+			for _, entry := range entries {
+				buf.Write(entry)
+			}
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+// readIndex reads an index in its serial form.
+func readIndex(r io.Reader) (Index, error) {
+	br := bufio.NewReader(r)
+	codec, err := binary.ReadUvarint(br)
+	if err != nil {
+		return Index{}, fmt.Errorf("invalid car index: %s", eofUnexpected(err))
+	}
+	if codec != IndexCodec {
+		return Index{}, fmt.Errorf("unsupported car index format 0x%x", codec)
+	}
+	idx := Index{make(map[string]uint64)}
+	var codeCount int32
+	if err := binary.Read(br, binary.LittleEndian, &codeCount); err != nil {
+		return Index{}, fmt.Errorf("invalid car index: %s", eofUnexpected(err))
+	}
+	for ; codeCount > 0; codeCount-- {
+		var code uint64
+		var widthCount int32
+		if err := binary.Read(br, binary.LittleEndian, &code); err != nil {
+			return Index{}, fmt.Errorf("invalid car index: %s", eofUnexpected(err))
+		}
+		if err := binary.Read(br, binary.LittleEndian, &widthCount); err != nil {
+			return Index{}, fmt.Errorf("invalid car index: %s", eofUnexpected(err))
+		}
+		for ; widthCount > 0; widthCount-- {
+			var width uint32
+			var size int64
+			if err := binary.Read(br, binary.LittleEndian, &width); err != nil {
+				return Index{}, fmt.Errorf("invalid car index: %s", eofUnexpected(err))
+			}
+			if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+				return Index{}, fmt.Errorf("invalid car index: %s", eofUnexpected(err))
+			}
+			if width <= 8 || size < 0 || size%int64(width) != 0 {
+				return Index{}, fmt.Errorf("invalid car index: %d bytes of entries of width %d", size, width)
+			}
+			// As in readSection, don't trust the declared size for allocation.
+			entries, err := ioutil.ReadAll(io.LimitReader(br, size))
+			if err != nil {
+				return Index{}, fmt.Errorf("invalid car index: %s", err)
+			}
+			if int64(len(entries)) != size {
+				return Index{}, fmt.Errorf("invalid car index: %s", io.ErrUnexpectedEOF)
+			}
+			for ; len(entries) > 0; entries = entries[width:] {
+				digest := entries[:width-8]
+				idx.offsets[string(joinMultihash(code, digest))] = binary.LittleEndian.Uint64(entries[width-8 : width])
+			}
+		}
+	}
+	return idx, nil
+}
+
+// splitMultihash returns the code and digest of a multihash.
+func splitMultihash(mh []byte) (uint64, []byte, error) {
+	code, n := binary.Uvarint(mh)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("invalid multihash")
+	}
+	length, m := binary.Uvarint(mh[n:])
+	if m <= 0 || uint64(len(mh)-n-m) != length {
+		return 0, nil, fmt.Errorf("invalid multihash")
+	}
+	return code, mh[n+m:], nil
+}
+
+// joinMultihash is the inverse of splitMultihash.
+func joinMultihash(code uint64, digest []byte) []byte {
+	mh := make([]byte, 0, 2*binary.MaxVarintLen64+len(digest))
+	var buf [binary.MaxVarintLen64]byte
+	mh = append(mh, buf[:binary.PutUvarint(buf[:], code)]...)
+	mh = append(mh, buf[:binary.PutUvarint(buf[:], uint64(len(digest)))]...)
+	return append(mh, digest...)
+}
+
+// eofUnexpected turns io.EOF into io.ErrUnexpectedEOF, for reads of things which must be present.
+func eofUnexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package car
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	cid "github.com/ipfs/go-cid"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// Reader reads a CARv1 stream, one block at a time.
+type Reader struct {
+	Header Header
+	r      *countingReader
+}
+
+// NewReader reads the header of a CARv1 stream, and returns a Reader for the
+// blocks which follow it.
+func NewReader(r io.Reader) (*Reader, error) {
+	cr := newCountingReader(r)
+	h, err := readHeader(cr)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{h, cr}, nil
+}
+
+// Next returns the next block in the stream, and the CID it's addressed by.
+// At the end of the stream, it returns io.EOF.
+//
+// Blocks are not checked against their CIDs here; cidlink.Link.Load does that,
+// so blocks served by Loader are checked when they're loaded.
+func (cr *Reader) Next() (cid.Cid, []byte, error) {
+	_, c, data, err := cr.next()
+	return c, data, err
+}
+
+// next is Next, but also returns the offset of the section in the stream.
+func (cr *Reader) next() (int64, cid.Cid, []byte, error) {
+	offset := cr.r.n
+	data, err := readSection(cr.r)
+	if err == io.EOF {
+		return offset, cid.Undef, nil, io.EOF
+	}
+	if err != nil {
+		return offset, cid.Undef, nil, fmt.Errorf("invalid car section at offset %d: %s", offset, err)
+	}
+	n, c, err := cid.CidFromBytes(data)
+	if err != nil {
+		return offset, cid.Undef, nil, fmt.Errorf("invalid car section at offset %d: %s", offset, err)
+	}
+	return offset, c, data[n:], nil
+}
+
+// Loader reads all the remaining blocks in the stream into memory, and
+// returns an ipld.Loader which serves them (for cidlink.Link.Load).
+// The Loader returns an error for any link not in the stream.
+func (cr *Reader) Loader() (ipld.Loader, error) {
+	blocks := make(map[cid.Cid][]byte)
+	for {
+		c, data, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		blocks[c] = data
+	}
+	return func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+		cl, ok := lnk.(cidlink.Link)
+		if !ok {
+			return nil, fmt.Errorf("car loader can only load cidlink.Link, not %T", lnk)
+		}
+		data, ok := blocks[cl.Cid]
+		if !ok {
+			return nil, fmt.Errorf("block %q not found in car", cl.Cid)
+		}
+		return bytes.NewReader(data), nil
+	}, nil
+}
@@ -0,0 +1,164 @@
+package car
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	cid "github.com/ipfs/go-cid"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// A CARv2 file wraps a CARv1 stream (the "data payload"), adding an index
+// of the blocks in it, so that they can be found without reading the whole
+// payload.  The layout is:
+//
+//	pragma    | 11 bytes: a CARv1 header, {"version": 2}
+//	header    | 40 bytes: see HeaderV2
+//	payload   | a complete CARv1 stream
+//	index     | see Index.WriteTo
+//
+// See https://github.com/ipld/specs/blob/master/block-layer/carv2.md
+// for the specification.
+
+// v2Pragma is how every CARv2 file begins.  Since it's a valid CARv1 header,
+// CARv1 readers (such as NewReader) reject CARv2 files with ErrUnsupportedVersion.
+var v2Pragma = []byte{0x0a, 0xa1, 0x67, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x02}
+
+const v2HeaderSize = 40
+
+// HeaderV2 is the header of a CARv2 file, which follows the pragma.
+// Offsets are from the start of the file.
+type HeaderV2 struct {
+	Characteristics [16]byte // A bitfield.  This package sets no bits, and ignores them when reading.
+	DataOffset      uint64   // Where the CARv1 payload begins.
+	DataSize        uint64   // The length of the CARv1 payload.
+	IndexOffset     uint64   // Where the index begins, or 0 if there is none.
+}
+
+func (h HeaderV2) marshal() []byte {
+	buf := make([]byte, v2HeaderSize)
+	copy(buf, h.Characteristics[:])
+	binary.LittleEndian.PutUint64(buf[16:], h.DataOffset)
+	binary.LittleEndian.PutUint64(buf[24:], h.DataSize)
+	binary.LittleEndian.PutUint64(buf[32:], h.IndexOffset)
+	return buf
+}
+
+func unmarshalHeaderV2(buf []byte) HeaderV2 {
+	var h HeaderV2
+	copy(h.Characteristics[:], buf)
+	h.DataOffset = binary.LittleEndian.Uint64(buf[16:])
+	h.DataSize = binary.LittleEndian.Uint64(buf[24:])
+	h.IndexOffset = binary.LittleEndian.Uint64(buf[32:])
+	return h
+}
+
+// WriteV2 writes a CARv2 file, with the given CARv1 stream (of the given size)
+// as its payload, and an index of it.
+//
+// The CARv1 stream is read twice: once to build the index (see BuildIndex),
+// and then again to copy it.  It's not held in memory; the index is.
+func WriteV2(w io.Writer, v1 io.ReaderAt, size int64) error {
+	idx, err := BuildIndex(io.NewSectionReader(v1, 0, size))
+	if err != nil {
+		return err
+	}
+	h := HeaderV2{
+		DataOffset:  uint64(len(v2Pragma) + v2HeaderSize),
+		DataSize:    uint64(size),
+		IndexOffset: uint64(len(v2Pragma)+v2HeaderSize) + uint64(size),
+	}
+	if _, err := w.Write(v2Pragma); err != nil {
+		return err
+	}
+	if _, err := w.Write(h.marshal()); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(v1, 0, size)); err != nil {
+		return err
+	}
+	_, err = idx.WriteTo(w)
+	return err
+}
+
+// ReaderV2 gives random access to the blocks in a CARv2 file.
+type ReaderV2 struct {
+	HeaderV2 HeaderV2
+	Header   Header // The header of the CARv1 payload (which has the roots).
+	Index    Index
+	r        io.ReaderAt
+}
+
+// OpenV2 reads the headers and index of a CARv2 file.
+// Nothing else is read until blocks are loaded (see Loader).
+//
+// If the file has no index, one is built by reading the whole payload.
+func OpenV2(r io.ReaderAt) (*ReaderV2, error) {
+	buf := make([]byte, len(v2Pragma)+v2HeaderSize)
+	if n, err := r.ReadAt(buf, 0); n < len(buf) {
+		return nil, fmt.Errorf("invalid carv2 header: %s", eofUnexpected(err))
+	}
+	if !bytes.Equal(buf[:len(v2Pragma)], v2Pragma) {
+		return nil, fmt.Errorf("invalid carv2 header: not a carv2 file")
+	}
+	cr := &ReaderV2{HeaderV2: unmarshalHeaderV2(buf[len(v2Pragma):]), r: r}
+	if cr.HeaderV2.DataOffset < uint64(len(buf)) || cr.HeaderV2.DataOffset > math.MaxInt64-cr.HeaderV2.DataSize {
+		return nil, fmt.Errorf("invalid carv2 header: payload at offset %d of size %d", cr.HeaderV2.DataOffset, cr.HeaderV2.DataSize)
+	}
+	v1, err := NewReader(cr.payload())
+	if err != nil {
+		return nil, err
+	}
+	cr.Header = v1.Header
+	if cr.HeaderV2.IndexOffset == 0 {
+		cr.Index, err = BuildIndex(cr.payload())
+	} else if cr.HeaderV2.IndexOffset > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid carv2 header: index at offset %d", cr.HeaderV2.IndexOffset)
+	} else {
+		cr.Index, err = readIndex(io.NewSectionReader(r, int64(cr.HeaderV2.IndexOffset), math.MaxInt64-int64(cr.HeaderV2.IndexOffset)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+func (cr *ReaderV2) payload() *io.SectionReader {
+	return io.NewSectionReader(cr.r, int64(cr.HeaderV2.DataOffset), int64(cr.HeaderV2.DataSize))
+}
+
+// Loader returns an ipld.Loader which serves blocks (for cidlink.Link.Load)
+// by looking them up in the index, and reading just that part of the file.
+// The Loader returns an error for any link not in the index.
+//
+// The Loader is safe for concurrent use if the io.ReaderAt is (as files are).
+func (cr *ReaderV2) Loader() ipld.Loader {
+	return func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+		cl, ok := lnk.(cidlink.Link)
+		if !ok {
+			return nil, fmt.Errorf("car loader can only load cidlink.Link, not %T", lnk)
+		}
+		offset, ok := cr.Index.Offset(cl.Cid)
+		if !ok || offset >= cr.HeaderV2.DataSize {
+			return nil, fmt.Errorf("block %q not found in car", cl.Cid)
+		}
+		sr := io.NewSectionReader(cr.r, int64(cr.HeaderV2.DataOffset+offset), int64(cr.HeaderV2.DataSize-offset))
+		data, err := readSection(newCountingReader(sr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid car section at offset %d: %s", offset, eofUnexpected(err))
+		}
+		n, c, err := cid.CidFromBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid car section at offset %d: %s", offset, err)
+		}
+		if !bytes.Equal(c.Hash(), cl.Hash()) {
+			return nil, fmt.Errorf("invalid car index: block %q is indexed at offset %d, but %q is there", cl.Cid, offset, c)
+		}
+		return bytes.NewReader(data[n:]), nil
+	}
+}
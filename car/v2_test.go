@@ -0,0 +1,100 @@
+package car_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	cid "github.com/ipfs/go-cid"
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/car"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+	"github.com/ipld/go-ipld-prime/traversal"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+	"github.com/ipld/go-ipld-prime/traversal/selector/builder"
+)
+
+func TestCarV2(t *testing.T) {
+	f := newFixture(t)
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	sAll, err := ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+		ssb.Matcher(),
+		ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+	)).Selector()
+	Require(t, err, ShouldEqual, nil)
+	var v1 bytes.Buffer
+	Require(t, car.WriteSelective(&v1, f.prog(), f.root, sAll), ShouldEqual, nil)
+
+	t.Run("index should have the offset of each block", func(t *testing.T) {
+		idx, err := car.BuildIndex(bytes.NewReader(v1.Bytes()))
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, idx.Len(), ShouldEqual, 4)
+		cr, err := car.NewReader(bytes.NewReader(v1.Bytes()))
+		Require(t, err, ShouldEqual, nil)
+		loader, err := cr.Loader()
+		Require(t, err, ShouldEqual, nil)
+		for _, c := range []cid.Cid{f.root, f.list, f.alpha, f.beta} {
+			offset, ok := idx.Offset(c)
+			Require(t, ok, ShouldEqual, true)
+			// Each section starts with its length, then the CID, then the block.
+			section := v1.Bytes()[offset:]
+			_, n := binary.Uvarint(section)
+			Wish(t, section[n:n+len(c.Bytes())], ShouldEqual, c.Bytes())
+			r, err := loader(cidlink.Link{Cid: c}, ipld.LinkContext{})
+			Require(t, err, ShouldEqual, nil)
+			Wish(t, r.(*bytes.Reader).Len() > 0, ShouldEqual, true)
+		}
+		_, ok := idx.Offset(cid.NewCidV1(0x55, f.root.Hash()))
+		Wish(t, ok, ShouldEqual, true) // same multihash, so same block.
+	})
+	t.Run("v2 file should serve blocks through the index", func(t *testing.T) {
+		var v2 bytes.Buffer
+		Require(t, car.WriteV2(&v2, bytes.NewReader(v1.Bytes()), int64(v1.Len())), ShouldEqual, nil)
+		Wish(t, v2.Bytes()[:11], ShouldEqual, []byte{0x0a, 0xa1, 0x67, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x02})
+
+		cr, err := car.OpenV2(bytes.NewReader(v2.Bytes()))
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, cr.HeaderV2.DataOffset, ShouldEqual, uint64(51))
+		Wish(t, cr.HeaderV2.DataSize, ShouldEqual, uint64(v1.Len()))
+		Wish(t, cr.HeaderV2.IndexOffset, ShouldEqual, uint64(51+v1.Len()))
+		Wish(t, cr.Header.Roots, ShouldEqual, []cid.Cid{f.root})
+		Wish(t, cr.Index.Len(), ShouldEqual, 4)
+
+		nb := basicnode.Style__Any{}.NewBuilder()
+		err = cidlink.Link{Cid: f.root}.Load(context.Background(), ipld.LinkContext{}, nb, cr.Loader())
+		Require(t, err, ShouldEqual, nil)
+		prog := f.prog()
+		prog.Cfg.LinkLoader = cr.Loader()
+		var visits int
+		err = prog.WalkMatching(nb.Build(), sAll, func(traversal.Progress, ipld.Node) error {
+			visits++
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, visits, ShouldEqual, 5)
+
+		unknown, err := cid.Prefix{Version: 1, Codec: 0x71, MhType: 0x12, MhLength: 32}.Sum([]byte("nope"))
+		Require(t, err, ShouldEqual, nil)
+		_, err = cr.Loader()(cidlink.Link{Cid: unknown}, ipld.LinkContext{})
+		Wish(t, err.Error(), ShouldEqual, `block "`+unknown.String()+`" not found in car`)
+	})
+	t.Run("v2 file without an index should be indexed when opened", func(t *testing.T) {
+		var v2 bytes.Buffer
+		Require(t, car.WriteV2(&v2, bytes.NewReader(v1.Bytes()), int64(v1.Len())), ShouldEqual, nil)
+		data := v2.Bytes()[:51+v1.Len()]
+		binary.LittleEndian.PutUint64(data[11+32:], 0)
+		cr, err := car.OpenV2(bytes.NewReader(data))
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, cr.Index.Len(), ShouldEqual, 4)
+	})
+	t.Run("v1 reader should reject v2 files", func(t *testing.T) {
+		var v2 bytes.Buffer
+		Require(t, car.WriteV2(&v2, bytes.NewReader(v1.Bytes()), int64(v1.Len())), ShouldEqual, nil)
+		_, err := car.NewReader(bytes.NewReader(v2.Bytes()))
+		Wish(t, err, ShouldEqual, car.ErrUnsupportedVersion{Version: 2})
+	})
+}
@@ -0,0 +1,131 @@
+package car
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	cid "github.com/ipfs/go-cid"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/traversal"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+)
+
+// Writer writes a CARv1 stream.
+//
+// Each block is written once: writing a block with a CID which was already
+// written is a no-op.  Blocks are not checked against their CIDs.
+// A Writer is not safe for concurrent use.
+type Writer struct {
+	w       io.Writer
+	written map[cid.Cid]struct{}
+}
+
+// NewWriter writes the header of a CARv1 stream with the given roots,
+// and returns a Writer for the blocks which follow it.
+func NewWriter(w io.Writer, roots []cid.Cid) (*Writer, error) {
+	if err := writeHeader(w, Header{Roots: roots, Version: 1}); err != nil {
+		return nil, err
+	}
+	return &Writer{w, make(map[cid.Cid]struct{})}, nil
+}
+
+// WriteBlock writes a block, unless a block with the same CID was already written.
+func (cw *Writer) WriteBlock(c cid.Cid, data []byte) error {
+	if _, ok := cw.written[c]; ok {
+		return nil
+	}
+	if err := writeSection(cw.w, c.Bytes(), data); err != nil {
+		return err
+	}
+	cw.written[c] = struct{}{}
+	return nil
+}
+
+// Storer returns an ipld.Storer which writes blocks to the CAR stream
+// as they're committed, so that cidlink.LinkBuilder can build directly into it.
+func (cw *Writer) Storer() ipld.Storer {
+	return func(ipld.LinkContext) (io.Writer, ipld.StoreCommitter, error) {
+		var buf bytes.Buffer
+		return &buf, func(lnk ipld.Link) error {
+			cl, ok := lnk.(cidlink.Link)
+			if !ok {
+				return fmt.Errorf("car writer can only store cidlink.Link, not %T", lnk)
+			}
+			return cw.WriteBlock(cl.Cid, buf.Bytes())
+		}, nil
+	}
+}
+
+// WriteSelective writes a CARv1 stream, with the given root, containing the
+// blocks covered by the selector when it's applied to the root: that is,
+// the root itself, and every block that traversal.WalkAdv loads when walking
+// from the root with the selector.  Blocks are written in the order they're
+// first loaded, so the stream can be read back in a single pass.
+//
+// Blocks are loaded using prog.Cfg.LinkLoader, and are written as they're
+// loaded, so the DAG doesn't need to be held in memory.  Each block is checked
+// against its CID before it's written, so a bad block is never written.
+// Other parts of the Config apply to the walk as usual; the root block counts
+// against its budgets too (see traversal.Progress.LoadRoot).
+func WriteSelective(w io.Writer, prog traversal.Progress, root cid.Cid, s selector.Selector) error {
+	var cfg traversal.Config
+	if prog.Cfg != nil {
+		cfg = *prog.Cfg
+	}
+	if cfg.LinkLoader == nil {
+		return fmt.Errorf("no link loader configured")
+	}
+	if cfg.LinkTargetNodeStyleChooser == nil {
+		return fmt.Errorf("no LinkTargetNodeStyleChooser configured")
+	}
+	cw, err := NewWriter(w, []cid.Cid{root})
+	if err != nil {
+		return err
+	}
+	load := cfg.LinkLoader
+	var mu sync.Mutex // loads may be concurrent; see Config.LinkPrefetchConcurrency.
+	cfg.LinkLoader = func(lnk ipld.Link, lnkCtx ipld.LinkContext) (io.Reader, error) {
+		cl, ok := lnk.(cidlink.Link)
+		if !ok {
+			return nil, fmt.Errorf("car writer can only load cidlink.Link, not %T", lnk)
+		}
+		r, err := load(lnk, lnkCtx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		// Link.Load checks the hash too, but only after we've returned the
+		//  data; so check it here as well, before the block goes in the stream.
+		c, err := cl.Prefix().Sum(data)
+		if err != nil {
+			return nil, err
+		}
+		if !c.Equals(cl.Cid) {
+			return nil, fmt.Errorf("hash mismatch!  %q (actual) != %q (expected)", c, cl.Cid)
+		}
+		mu.Lock()
+		err = cw.WriteBlock(cl.Cid, data)
+		mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+	prog.Cfg = &cfg
+
+	n, err := prog.LoadRoot(cidlink.Link{Cid: root})
+	if err != nil {
+		return err
+	}
+	return prog.WalkAdv(n, s, func(traversal.Progress, ipld.Node, traversal.VisitReason) error {
+		return nil
+	})
+}
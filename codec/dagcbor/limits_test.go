@@ -0,0 +1,80 @@
+package dagcbor
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	"github.com/ipld/go-ipld-prime/codec"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+func TestDecodeLimits(t *testing.T) {
+	decode := func(serial string, opts DecodeOptions) error {
+		nb := basicnode.Style__Any{}.NewBuilder()
+		return DecodeWithOptions(nb, bytes.NewBufferString(serial), opts)
+	}
+	t.Run("deep nesting is rejected", func(t *testing.T) {
+		serial := strings.Repeat("\x81", 100000) + "\x80"
+		opts := DecodeOptions{Limits: codec.DecodeLimits{MaxDepth: 64}}
+		Wish(t, decode(serial, opts), ShouldEqual, codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Depth, Max: 64, Got: 65})
+		opts.Strict = true
+		Wish(t, decode(serial, opts), ShouldEqual, codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Depth, Max: 64, Got: 65})
+	})
+	t.Run("nesting within the limit is fine", func(t *testing.T) {
+		serial := strings.Repeat("\x81", 63) + "\x80"
+		Wish(t, decode(serial, DecodeOptions{Limits: codec.DecodeLimits{MaxDepth: 64}}), ShouldEqual, nil)
+	})
+	t.Run("huge declared length is rejected before allocating", func(t *testing.T) {
+		serial := "\xbb\x10\x00\x00\x00\x00\x00\x00\x00" // map of 2^60 entries.
+		err := decode(serial, DecodeOptions{Limits: codec.DecodeLimits{MaxLength: 1024}})
+		Wish(t, err, ShouldEqual, codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Length, Max: 1024, Got: 1 << 60})
+	})
+	t.Run("indefinite length is counted as it goes", func(t *testing.T) {
+		serial := "\x9f" + strings.Repeat("\x01", 5) + "\xff"
+		err := decode(serial, DecodeOptions{Limits: codec.DecodeLimits{MaxLength: 4}})
+		Wish(t, err, ShouldEqual, codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Length, Max: 4, Got: 5})
+	})
+	t.Run("total allocation is bounded", func(t *testing.T) {
+		serial := "\x83" + "\x44abcd" + "\x44abcd" + "\x44abcd" // three 4-byte strings, in a list of 3.
+		err := decode(serial, DecodeOptions{Limits: codec.DecodeLimits{MaxAllocation: 10}})
+		Wish(t, err, ShouldEqual, codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Allocation, Max: 10, Got: 11})
+		Wish(t, decode(serial, DecodeOptions{Limits: codec.DecodeLimits{MaxAllocation: 15}}), ShouldEqual, nil)
+	})
+	t.Run("strict decoding checks allocation before the tokenizer allocates", func(t *testing.T) {
+		// Bytes declaring 16 MiB, with none of them present:
+		//  the tokenizer would allocate them all before finding that out.
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		err := decode("\x5a\x01\x00\x00\x00", DecodeOptions{Strict: true, Limits: codec.DecodeLimits{MaxAllocation: 1 << 20}})
+		runtime.ReadMemStats(&after)
+		Wish(t, err == nil, ShouldEqual, false)
+		Wish(t, after.TotalAlloc-before.TotalAlloc < 1<<20, ShouldEqual, true)
+
+	})
+	t.Run("strict decoding reads no more input than the allocation limit", func(t *testing.T) {
+		r := &countingReader{r: strings.NewReader("\x5a\x00\x20\x00\x00" + strings.Repeat("x", 2<<20))} // 2 MiB of bytes.
+		err := DecodeWithOptions(basicnode.Style__Any{}.NewBuilder(), r, DecodeOptions{Strict: true, Limits: codec.DecodeLimits{MaxAllocation: 1 << 20}})
+		Wish(t, err, ShouldEqual, codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Allocation, Max: 1 << 20, Got: 1<<20 + 1})
+		Wish(t, r.n, ShouldEqual, int64(1<<20+1))
+	})
+	t.Run("error message", func(t *testing.T) {
+		err := codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Depth, Max: 64, Got: 65}
+		Wish(t, err.Error(), ShouldEqual, "decode limit exceeded: depth of 65 is over the limit of 64")
+	})
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
@@ -2,6 +2,8 @@ package dagcbor
 
 import (
 	"fmt"
+	"math"
+	"sort"
 
 	"github.com/polydawn/refmt/shared"
 	"github.com/polydawn/refmt/tok"
@@ -14,11 +16,34 @@ import (
 // except for the `case ipld.ReprKind_Link` block,
 // which is dag-cbor's special sauce for schemafree links.
 func Marshal(n ipld.Node, sink shared.TokenSink) error {
+	return MarshalWithOptions(n, sink, EncodeOptions{})
+}
+
+// EncodeOptions holds the options for encoding dag-cbor.
+// The zero value gives the default behavior (as in Marshal and Encoder).
+type EncodeOptions struct {
+	// Strict makes the encoding canonical, as the DAG-CBOR specification
+	// requires: so that the same data always encodes to the same bytes (and
+	// thus hashes to the same CID), no matter how the Node was built.
+	//
+	// Map entries are sorted by key, shortest key first, then bytewise
+	// (rather than emitted in MapIterator order, as they are by default);
+	// and floats which can't be represented canonically -- NaN and the
+	// infinities -- are rejected.
+	//
+	// (Some of what the specification requires is done regardless:
+	// integers and lengths always use the smallest encoding possible,
+	// lengths are never indefinite, and floats are always 64-bit.)
+	Strict bool
+}
+
+// MarshalWithOptions is Marshal, with options.
+func MarshalWithOptions(n ipld.Node, sink shared.TokenSink, opts EncodeOptions) error {
 	var tk tok.Token
-	return marshal(n, &tk, sink)
+	return marshal(n, &tk, sink, opts)
 }
 
-func marshal(n ipld.Node, tk *tok.Token, sink shared.TokenSink) error {
+func marshal(n ipld.Node, tk *tok.Token, sink shared.TokenSink, opts EncodeOptions) error {
 	switch n.ReprKind() {
 	case ipld.ReprKind_Invalid:
 		return fmt.Errorf("cannot traverse a node that is undefined")
@@ -34,21 +59,27 @@ func marshal(n ipld.Node, tk *tok.Token, sink shared.TokenSink) error {
 			return err
 		}
 		// Emit map contents (and recurse).
-		for itr := n.MapIterator(); !itr.Done(); {
-			k, v, err := itr.Next()
-			if err != nil {
-				return err
-			}
-			tk.Type = tok.TString
-			tk.Str, err = k.AsString()
-			if err != nil {
+		if opts.Strict {
+			if err := marshalSortedEntries(n, tk, sink, opts); err != nil {
 				return err
 			}
-			if _, err := sink.Step(tk); err != nil {
-				return err
-			}
-			if err := marshal(v, tk, sink); err != nil {
-				return err
+		} else {
+			for itr := n.MapIterator(); !itr.Done(); {
+				k, v, err := itr.Next()
+				if err != nil {
+					return err
+				}
+				tk.Type = tok.TString
+				tk.Str, err = k.AsString()
+				if err != nil {
+					return err
+				}
+				if _, err := sink.Step(tk); err != nil {
+					return err
+				}
+				if err := marshal(v, tk, sink, opts); err != nil {
+					return err
+				}
 			}
 		}
 		// Emit map close.
@@ -69,7 +100,7 @@ func marshal(n ipld.Node, tk *tok.Token, sink shared.TokenSink) error {
 			if err != nil {
 				return err
 			}
-			if err := marshal(v, tk, sink); err != nil {
+			if err := marshal(v, tk, sink, opts); err != nil {
 				return err
 			}
 		}
@@ -100,6 +131,9 @@ func marshal(n ipld.Node, tk *tok.Token, sink shared.TokenSink) error {
 		if err != nil {
 			return err
 		}
+		if opts.Strict && (math.IsNaN(v) || math.IsInf(v, 0)) {
+			return fmt.Errorf("strict dag-cbor encoding rejected: float %v is not allowed", v)
+		}
 		tk.Type = tok.TFloat64
 		tk.Float64 = v
 		_, err = sink.Step(tk)
@@ -142,3 +176,44 @@ func marshal(n ipld.Node, tk *tok.Token, sink shared.TokenSink) error {
 		panic("unreachable")
 	}
 }
+
+// marshalSortedEntries emits the entries of a map in canonical order:
+// shortest key first, then bytewise.
+func marshalSortedEntries(n ipld.Node, tk *tok.Token, sink shared.TokenSink, opts EncodeOptions) error {
+	type entry struct {
+		k string
+		v ipld.Node
+	}
+	entries := make([]entry, 0, n.Length())
+	for itr := n.MapIterator(); !itr.Done(); {
+		k, v, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		ks, err := k.AsString()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{ks, v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if len(entries[i].k) != len(entries[j].k) {
+			return len(entries[i].k) < len(entries[j].k)
+		}
+		return entries[i].k < entries[j].k
+	})
+	for i, e := range entries {
+		if i > 0 && e.k == entries[i-1].k {
+			return fmt.Errorf("strict dag-cbor encoding rejected: duplicate map key %q", e.k)
+		}
+		tk.Type = tok.TString
+		tk.Str = e.k
+		if _, err := sink.Step(tk); err != nil {
+			return err
+		}
+		if err := marshal(e.v, tk, sink, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,11 +1,14 @@
 package dagcbor
 
 import (
+	"bytes"
 	"io"
+	"io/ioutil"
 
 	"github.com/polydawn/refmt/cbor"
 
 	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec"
 	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 )
 
@@ -32,6 +35,78 @@ func Decoder(na ipld.NodeAssembler, r io.Reader) error {
 	return Unmarshal(na, cbor.NewDecoder(cbor.DecodeOptions{}, r))
 }
 
+// DecodeOptions holds the options for decoding dag-cbor.
+// The zero value gives the default behavior (as in Decoder).
+type DecodeOptions struct {
+	// Strict rejects any input which isn't in the canonical form that the
+	// DAG-CBOR specification requires, rather than accepting any CBOR that
+	// can be parsed.  So a block that decodes successfully will re-encode to
+	// exactly the same bytes (using EncodeOptions.Strict).
+	//
+	// Rejected are: indefinite-length items; integers and lengths not in their
+	// smallest encoding; map keys which aren't strings, or which are unsorted
+	// or duplicated; tags other than 42 (links); floats which aren't 64-bit,
+	// or are NaN or infinite; simple values other than true, false and null;
+	// strings which aren't UTF-8; and anything after the end of the item.
+	// Errors give the byte offset of the problem.
+	//
+	// The whole input is read into memory before decoding begins.
+	// If Limits.MaxAllocation is set, the input may be no longer than that
+	// (and no more than one byte past it is read); otherwise the read is unbounded.
+	Strict bool
+
+	// Limits bounds the resources decoding may use; see codec.DecodeLimits.
+	//
+	// Without Strict, the tokenizer allocates the declared size of each
+	// string or bytes (of up to 32 MiB) before the limits can be checked.
+	// With Strict, the limits are checked on the whole block first, so that
+	// nothing larger than the block itself (which MaxAllocation also bounds)
+	// is ever allocated.
+	Limits codec.DecodeLimits
+}
+
+// DecodeWithOptions is Decoder, with options.
+//
+// As with EncodeWithOptions, to have cidlink use particular options,
+// register a decoder which uses them (see DecodeOptions.Decoder).
+func DecodeWithOptions(na ipld.NodeAssembler, r io.Reader, opts DecodeOptions) error {
+	if opts == (DecodeOptions{}) {
+		return Decoder(na, r)
+	}
+	// The fast path is not used here; we can't know if it's as strict,
+	//  nor if it would honor the limits.
+	if !opts.Strict {
+		return UnmarshalWithLimits(na, cbor.NewDecoder(cbor.DecodeOptions{}, r), opts.Limits)
+	}
+	max := opts.Limits.MaxAllocation
+	if max > 0 {
+		r = io.LimitReader(r, max+1)
+	}
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if max > 0 && int64(len(buf)) > max {
+		return codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Allocation, Max: max, Got: int64(len(buf))}
+	}
+	if err := checkCanonical(buf, opts.Limits); err != nil {
+		return err
+	}
+	return UnmarshalWithLimits(na, cbor.NewDecoder(cbor.DecodeOptions{}, bytes.NewReader(buf)), opts.Limits)
+}
+
+// Decoder returns a cidlink.MulticodecDecoder which decodes with these options.
+// Registering it makes cidlink.Link.Load use the options for every dag-cbor block:
+//
+//	cidlink.RegisterMulticodecDecoder(0x71, dagcbor.DecodeOptions{
+//		Limits: codec.DecodeLimits{MaxDepth: 256, MaxAllocation: 1 << 20},
+//	}.Decoder())
+func (opts DecodeOptions) Decoder() cidlink.MulticodecDecoder {
+	return func(na ipld.NodeAssembler, r io.Reader) error {
+		return DecodeWithOptions(na, r, opts)
+	}
+}
+
 func Encoder(n ipld.Node, w io.Writer) error {
 	// Probe for a builtin fast path.  Shortcut to that if possible.
 	//  (ipldcbor.Node supports this, for example.)
@@ -44,3 +119,26 @@ func Encoder(n ipld.Node, w io.Writer) error {
 	// Okay, generic inspection path.
 	return Marshal(n, cbor.NewEncoder(w))
 }
+
+// EncodeWithOptions is Encoder, with options.
+//
+// To have cidlink use particular options (e.g. to make every dag-cbor block
+// canonical), register an encoder which uses them (see EncodeOptions.Encoder).
+func EncodeWithOptions(n ipld.Node, w io.Writer, opts EncodeOptions) error {
+	// The fast path is only usable for the default options,
+	//  since we can't know what options it implements.
+	if opts == (EncodeOptions{}) {
+		return Encoder(n, w)
+	}
+	return MarshalWithOptions(n, cbor.NewEncoder(w), opts)
+}
+
+// Encoder returns a cidlink.MulticodecEncoder which encodes with these options.
+// Registering it makes cidlink.LinkBuilder use the options for every dag-cbor block:
+//
+//	cidlink.RegisterMulticodecEncoder(0x71, dagcbor.EncodeOptions{Strict: true}.Encoder())
+func (opts EncodeOptions) Encoder() cidlink.MulticodecEncoder {
+	return func(n ipld.Node, w io.Writer) error {
+		return EncodeWithOptions(n, w, opts)
+	}
+}
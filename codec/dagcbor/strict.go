@@ -0,0 +1,219 @@
+package dagcbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/ipld/go-ipld-prime/codec"
+)
+
+// CBOR major types.
+const (
+	majorUint   = 0
+	majorNegint = 1
+	majorBytes  = 2
+	majorString = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+// checkCanonical checks that a buffer holds exactly one CBOR item, encoded
+// in the one way that DAG-CBOR permits -- which is the way that Marshal with
+// EncodeOptions.Strict encodes it.  It returns an error giving the offset
+// of the first non-canonical thing it finds, if any.
+//
+// It works on the raw bytes, rather than tokens, because the tokenizer
+// (by design) doesn't tell us how things were encoded.
+//
+// It also enforces the given limits, so that they're checked before the
+// tokenizer allocates anything for a declared length.  (It must, at least for
+// depth, since it recurses.)
+func checkCanonical(buf []byte, limits codec.DecodeLimits) error {
+	c := canonicalChecker{buf: buf, budget: codec.NewDecodeBudget(limits)}
+	if err := c.item(); err != nil {
+		return err
+	}
+	if c.pos != len(c.buf) {
+		return c.errorf(c.pos, "unexpected content after end of item")
+	}
+	return nil
+}
+
+type canonicalChecker struct {
+	buf    []byte
+	pos    int
+	budget *codec.DecodeBudget // nil if there are no limits.
+}
+
+func (c *canonicalChecker) errorf(offset int, format string, args ...interface{}) error {
+	return fmt.Errorf("strict dag-cbor decoding rejected at offset %d: %s", offset, fmt.Sprintf(format, args...))
+}
+
+// head reads the initial byte of an item, and its argument (if any), checking
+// that the argument is minimally encoded.  For major type 7, the argument
+// (a float, or a simple value) isn't checked here.
+func (c *canonicalChecker) head() (major byte, info byte, arg uint64, err error) {
+	start := c.pos
+	if c.pos >= len(c.buf) {
+		return 0, 0, 0, c.errorf(start, "unexpected end of input")
+	}
+	major, info = c.buf[c.pos]>>5, c.buf[c.pos]&0x1f
+	c.pos++
+	var size int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		size = 1 << (info - 24)
+	case info == 31:
+		if major == majorSimple {
+			return 0, 0, 0, c.errorf(start, "unexpected break")
+		}
+		return 0, 0, 0, c.errorf(start, "indefinite-length items are not allowed")
+	default:
+		return 0, 0, 0, c.errorf(start, "reserved additional info %d", info)
+	}
+	if len(c.buf)-c.pos < size {
+		return 0, 0, 0, c.errorf(start, "unexpected end of input")
+	}
+	var b [8]byte
+	copy(b[8-size:], c.buf[c.pos:c.pos+size])
+	arg = binary.BigEndian.Uint64(b[:])
+	c.pos += size
+	if major != majorSimple {
+		if (size == 1 && arg < 24) || (size > 1 && arg < 1<<(uint(size)*4)) {
+			return 0, 0, 0, c.errorf(start, "integer or length is not minimally encoded")
+		}
+	}
+	return major, info, arg, nil
+}
+
+func (c *canonicalChecker) item() error {
+	start := c.pos
+	major, info, arg, err := c.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case majorUint, majorNegint:
+		if arg > math.MaxInt64 {
+			return c.errorf(start, "integer out of range")
+		}
+		return nil
+	case majorBytes, majorString:
+		if arg > uint64(len(c.buf)-c.pos) {
+			return c.errorf(start, "declared length %d exceeds the remaining input", arg)
+		}
+		if err := c.budget.Alloc(int(arg)); err != nil {
+			return err
+		}
+		content := c.buf[c.pos : c.pos+int(arg)]
+		c.pos += int(arg)
+		if major == majorString && !utf8.Valid(content) {
+			return c.errorf(start, "string is not valid UTF-8")
+		}
+		return nil
+	case majorArray:
+		if err := c.enter(start, arg, 1); err != nil {
+			return err
+		}
+		defer c.budget.Leave()
+		for i := uint64(0); i < arg; i++ {
+			if err := c.item(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case majorMap:
+		if err := c.enter(start, arg, 2); err != nil {
+			return err
+		}
+		defer c.budget.Leave()
+		var prev []byte
+		for i := uint64(0); i < arg; i++ {
+			keyStart := c.pos
+			if c.pos < len(c.buf) && c.buf[c.pos]>>5 != majorString {
+				return c.errorf(keyStart, "map keys must be strings")
+			}
+			if err := c.item(); err != nil {
+				return err
+			}
+			// Keys are minimally encoded strings by now, so comparing their
+			// encoded forms compares length first, then bytes, as required.
+			key := c.buf[keyStart:c.pos]
+			if prev != nil {
+				switch cmp := compareKeys(prev, key); {
+				case cmp == 0:
+					return c.errorf(keyStart, "duplicate map key")
+				case cmp > 0:
+					return c.errorf(keyStart, "map keys are not in canonical order")
+				}
+			}
+			prev = key
+			if err := c.item(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case majorTag:
+		if arg != linkTag {
+			return c.errorf(start, "tag %d is not allowed; only %d (links)", arg, linkTag)
+		}
+		contentStart := c.pos
+		if c.pos >= len(c.buf) || c.buf[c.pos]>>5 != majorBytes {
+			return c.errorf(contentStart, "links must be bytes")
+		}
+		if err := c.item(); err != nil {
+			return err
+		}
+		return nil
+	case majorSimple:
+		switch info {
+		case 20, 21, 22: // false, true, null.
+			return nil
+		case 27:
+			if f := math.Float64frombits(arg); math.IsNaN(f) || math.IsInf(f, 0) {
+				return c.errorf(start, "float %v is not allowed", f)
+			}
+			return nil
+		case 25, 26:
+			return c.errorf(start, "floats must be 64-bit")
+		default:
+			return c.errorf(start, "simple value %d is not allowed", arg)
+		}
+	default:
+		panic("unreachable")
+	}
+}
+
+// enter checks the declared length of an array or map, whose entries each
+// take at least minSize bytes, against the limits (if there are any).
+// A length which can't fit in the remaining input is rejected up front, too,
+// since it can't be represented as an int for the budget in general.
+// Each enter that doesn't return an error must be paired with a budget.Leave.
+func (c *canonicalChecker) enter(start int, length uint64, minSize int) error {
+	if c.budget == nil {
+		return nil
+	}
+	if length > uint64((len(c.buf)-c.pos)/minSize) {
+		return c.errorf(start, "declared length %d exceeds the remaining input", length)
+	}
+	return c.budget.Enter(int(length))
+}
+
+// compareKeys compares two encoded map keys in canonical order:
+// shortest first, then bytewise.
+func compareKeys(a, b []byte) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return bytes.Compare(a, b)
+}
@@ -0,0 +1,138 @@
+package dagcbor
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+func TestStrictEncoding(t *testing.T) {
+	build := func(keys ...string) ipld.Node {
+		return fluent.MustBuildMap(basicnode.Style__Map{}, len(keys), func(na fluent.MapAssembler) {
+			for i, k := range keys {
+				na.AssembleEntry(k).CreateMap(2, func(na fluent.MapAssembler) {
+					na.AssembleEntry("zz").AssignInt(i)
+					na.AssembleEntry("a").AssignFloat(1.5)
+				})
+			}
+		})
+	}
+	encode := func(n ipld.Node, opts EncodeOptions) (string, error) {
+		var buf bytes.Buffer
+		err := EncodeWithOptions(n, &buf, opts)
+		return buf.String(), err
+	}
+	t.Run("map keys should be sorted shortest first, then bytewise", func(t *testing.T) {
+		s, err := encode(build("bb", "c", "aaa", "a"), EncodeOptions{Strict: true})
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, s, ShouldEqual, "\xa4"+
+			"aa\xa2aa\xfb\x3f\xf8\x00\x00\x00\x00\x00\x00bzz\x03"+
+			"ac\xa2aa\xfb\x3f\xf8\x00\x00\x00\x00\x00\x00bzz\x01"+
+			"bbb\xa2aa\xfb\x3f\xf8\x00\x00\x00\x00\x00\x00bzz\x00"+
+			"caaa\xa2aa\xfb\x3f\xf8\x00\x00\x00\x00\x00\x00bzz\x02")
+	})
+	t.Run("default encoding should keep iteration order", func(t *testing.T) {
+		s, err := encode(build("bb", "a"), EncodeOptions{})
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, s, ShouldEqual, "\xa2"+
+			"bbb\xa2bzz\x00aa\xfb\x3f\xf8\x00\x00\x00\x00\x00\x00"+
+			"aa\xa2bzz\x01aa\xfb\x3f\xf8\x00\x00\x00\x00\x00\x00")
+	})
+	t.Run("encoder for cidlink should use the options", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EncodeOptions{Strict: true}.Encoder()(build("bb", "a"), &buf)
+		Require(t, err, ShouldEqual, nil)
+		s, _ := encode(build("bb", "a"), EncodeOptions{Strict: true})
+		Wish(t, buf.String(), ShouldEqual, s)
+	})
+	t.Run("integers should use the smallest encoding", func(t *testing.T) {
+		for _, tc := range []struct {
+			i      int
+			serial string
+		}{
+			{23, "\x17"},
+			{24, "\x18\x18"},
+			{256, "\x19\x01\x00"},
+			{-1, "\x20"},
+			{-25, "\x38\x18"},
+			{1 << 32, "\x1b\x00\x00\x00\x01\x00\x00\x00\x00"},
+		} {
+			s, err := encode(basicnode.NewInt(tc.i), EncodeOptions{Strict: true})
+			Require(t, err, ShouldEqual, nil)
+			Wish(t, s, ShouldEqual, tc.serial)
+		}
+	})
+	t.Run("NaN and infinities should be rejected", func(t *testing.T) {
+		for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+			_, err := encode(basicnode.NewFloat(f), EncodeOptions{Strict: true})
+			Require(t, err != nil, ShouldEqual, true)
+		}
+		_, err := encode(basicnode.NewFloat(math.Inf(1)), EncodeOptions{Strict: true})
+		Wish(t, err.Error(), ShouldEqual, "strict dag-cbor encoding rejected: float +Inf is not allowed")
+	})
+}
+
+func TestStrictDecoding(t *testing.T) {
+	decode := func(serial string) (ipld.Node, error) {
+		nb := basicnode.Style__Any{}.NewBuilder()
+		err := DecodeWithOptions(nb, bytes.NewBufferString(serial), DecodeOptions{Strict: true})
+		if err != nil {
+			return nil, err
+		}
+		return nb.Build(), nil
+	}
+	t.Run("canonical input should decode and re-encode identically", func(t *testing.T) {
+		for _, serial := range []string{
+			"\xa2aa\x01bbb\x82\xf5\xf6",
+			"\x83\x18\x18\x38\x18\xfb\x3f\xf8\x00\x00\x00\x00\x00\x00",
+			"\xd8\x2a\x58\x25\x00\x01\x71\x12\x20" + string(make([]byte, 32)),
+			"\x62\xc3\xa9",
+		} {
+			n, err := decode(serial)
+			Require(t, err, ShouldEqual, nil)
+			var buf bytes.Buffer
+			Require(t, EncodeWithOptions(n, &buf, EncodeOptions{Strict: true}), ShouldEqual, nil)
+			Wish(t, buf.String(), ShouldEqual, serial)
+		}
+	})
+	t.Run("non-canonical input should be rejected", func(t *testing.T) {
+		for _, tc := range []struct {
+			name   string
+			serial string
+			err    string
+		}{
+			{"indefinite-length map", "\xbfaa\x01\xff", "at offset 0: indefinite-length items are not allowed"},
+			{"indefinite-length list", "\x81\x9f\xff", "at offset 1: indefinite-length items are not allowed"},
+			{"non-minimal int", "\x82\x01\x18\x17", "at offset 2: integer or length is not minimally encoded"},
+			{"non-minimal length", "\x59\x00\x01a", "at offset 0: integer or length is not minimally encoded"},
+			{"unsorted keys", "\xa2bbb\x01aa\x02", "at offset 5: map keys are not in canonical order"},
+			{"keys sorted bytewise only", "\xa2bab\x01ab\x02", "at offset 5: map keys are not in canonical order"},
+			{"duplicate keys", "\xa2aa\x01aa\x02", "at offset 4: duplicate map key"},
+			{"non-string keys", "\xa1\x01\x02", "at offset 1: map keys must be strings"},
+			{"other tags", "\xc1\x01", "at offset 0: tag 1 is not allowed; only 42 (links)"},
+			{"32-bit floats", "\xfa\x3f\xc0\x00\x00", "at offset 0: floats must be 64-bit"},
+			{"NaN", "\xfb\x7f\xf8\x00\x00\x00\x00\x00\x00", "at offset 0: float NaN is not allowed"},
+			{"undefined", "\xf7", "at offset 0: simple value 23 is not allowed"},
+			{"invalid UTF-8", "\x81\x61\xff", "at offset 1: string is not valid UTF-8"},
+			{"trailing content", "\x01\x02", "at offset 1: unexpected content after end of item"},
+			{"truncated", "\x82\x01", "at offset 2: unexpected end of input"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := decode(tc.serial)
+				Require(t, err != nil, ShouldEqual, true)
+				Wish(t, err.Error(), ShouldEqual, "strict dag-cbor decoding rejected "+tc.err)
+			})
+		}
+	})
+	t.Run("non-canonical input should be accepted by default", func(t *testing.T) {
+		nb := basicnode.Style__Any{}.NewBuilder()
+		err := DecodeWithOptions(nb, bytes.NewBufferString("\xa2bbb\x01aa\x18\x02"), DecodeOptions{})
+		Require(t, err, ShouldEqual, nil)
+	})
+}
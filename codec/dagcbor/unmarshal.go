@@ -10,6 +10,7 @@ import (
 	"github.com/polydawn/refmt/tok"
 
 	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec"
 	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 )
 
@@ -22,20 +23,31 @@ var (
 // which has dag-cbor's special sauce for detecting schemafree links.
 
 func Unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource) error {
+	return UnmarshalWithLimits(na, tokSrc, codec.DecodeLimits{})
+}
+
+// UnmarshalWithLimits is Unmarshal, halting with a codec.ErrDecodeLimitExceeded
+// if the data exceeds any of the given limits.
+func UnmarshalWithLimits(na ipld.NodeAssembler, tokSrc shared.TokenSource, limits codec.DecodeLimits) error {
 	var tk tok.Token
-	done, err := tokSrc.Step(&tk)
+	return unmarshalNext(na, tokSrc, &tk, codec.NewDecodeBudget(limits))
+}
+
+// unmarshalNext steps to the next token, then unmarshals starting from it.
+func unmarshalNext(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token, budget *codec.DecodeBudget) error {
+	done, err := tokSrc.Step(tk)
 	if err != nil {
 		return err
 	}
 	if done && !tk.Type.IsValue() {
 		return fmt.Errorf("unexpected eof")
 	}
-	return unmarshal(na, tokSrc, &tk)
+	return unmarshal(na, tokSrc, tk, budget)
 }
 
 // starts with the first token already primed.  Necessary to get recursion
 //  to flow right without a peek+unpeek system.
-func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token) error {
+func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token, budget *codec.DecodeBudget) error {
 	// FUTURE: check for schema.TypedNodeBuilder that's going to parse a Link (they can slurp any token kind they want).
 	switch tk.Type {
 	case tok.TMapOpen:
@@ -45,6 +57,10 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 			expectLen = math.MaxInt32
 			allocLen = 0
 		}
+		if err := budget.Enter(tk.Length); err != nil {
+			return err
+		}
+		defer budget.Leave()
 		ma, err := na.BeginMap(allocLen)
 		if err != nil {
 			return err
@@ -70,11 +86,19 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 			if observedLen > expectLen {
 				return fmt.Errorf("unexpected continuation of map elements beyond declared length")
 			}
+			if expectLen == math.MaxInt32 {
+				if err := budget.Entry(observedLen); err != nil {
+					return err
+				}
+			}
+			if err := budget.Alloc(len(tk.Str)); err != nil {
+				return err
+			}
 			mva, err := ma.AssembleEntry(tk.Str)
 			if err != nil { // return in error if the key was rejected
 				return err
 			}
-			err = Unmarshal(mva, tokSrc)
+			err = unmarshalNext(mva, tokSrc, tk, budget)
 			if err != nil { // return in error if some part of the recursion errored
 				return err
 			}
@@ -88,6 +112,10 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 			expectLen = math.MaxInt32
 			allocLen = 0
 		}
+		if err := budget.Enter(tk.Length); err != nil {
+			return err
+		}
+		defer budget.Leave()
 		la, err := na.BeginList(allocLen)
 		if err != nil {
 			return err
@@ -109,7 +137,12 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 				if observedLen > expectLen {
 					return fmt.Errorf("unexpected continuation of array elements beyond declared length")
 				}
-				err := unmarshal(la.AssembleValue(), tokSrc, tk)
+				if expectLen == math.MaxInt32 {
+					if err := budget.Entry(observedLen); err != nil {
+						return err
+					}
+				}
+				err := unmarshal(la.AssembleValue(), tokSrc, tk, budget)
 				if err != nil { // return in error if some part of the recursion errored
 					return err
 				}
@@ -120,8 +153,14 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 	case tok.TNull:
 		return na.AssignNull()
 	case tok.TString:
+		if err := budget.Alloc(len(tk.Str)); err != nil {
+			return err
+		}
 		return na.AssignString(tk.Str)
 	case tok.TBytes:
+		if err := budget.Alloc(len(tk.Bytes)); err != nil {
+			return err
+		}
 		if !tk.Tagged {
 			return na.AssignBytes(tk.Bytes)
 		}
@@ -0,0 +1,36 @@
+package dagjson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	"github.com/ipld/go-ipld-prime/codec"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+func TestDecodeLimits(t *testing.T) {
+	decode := func(serial string, limits codec.DecodeLimits) error {
+		nb := basicnode.Style__Any{}.NewBuilder()
+		return DecodeWithOptions(nb, bytes.NewBufferString(serial), DecodeOptions{Limits: limits})
+	}
+	t.Run("deep nesting is rejected", func(t *testing.T) {
+		serial := strings.Repeat("[", 100000) + strings.Repeat("]", 100000)
+		err := decode(serial, codec.DecodeLimits{MaxDepth: 64})
+		Wish(t, err, ShouldEqual, codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Depth, Max: 64, Got: 65})
+	})
+	t.Run("long maps are rejected", func(t *testing.T) {
+		err := decode(`{"a":1,"b":2,"c":3}`, codec.DecodeLimits{MaxLength: 2})
+		Wish(t, err, ShouldEqual, codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Length, Max: 2, Got: 3})
+	})
+	t.Run("total allocation is bounded", func(t *testing.T) {
+		err := decode(`["abcd","abcd","abcd"]`, codec.DecodeLimits{MaxAllocation: 10})
+		Wish(t, err, ShouldEqual, codec.ErrDecodeLimitExceeded{Limit: codec.DecodeLimitKind_Allocation, Max: 10, Got: 11})
+	})
+	t.Run("links are unaffected", func(t *testing.T) {
+		err := decode(`[{"/":"bafyreiejkvsvdq4smz44yuwhfymcuvqzavveoj2at3utujwqlllspsqr6q"}]`, codec.DecodeLimits{MaxDepth: 1})
+		Wish(t, err, ShouldEqual, nil)
+	})
+}
@@ -1,7 +1,9 @@
 package dagjson
 
 import (
+	"encoding/base64"
 	"fmt"
+	"sort"
 
 	"github.com/polydawn/refmt/shared"
 	"github.com/polydawn/refmt/tok"
@@ -11,10 +13,21 @@ import (
 )
 
 // This should be identical to the general feature in the parent package,
-// except for the `case ipld.ReprKind_Link` block,
-// which is dag-json's special sauce for schemafree links.
+// except for the `case ipld.ReprKind_Link` and `case ipld.ReprKind_Bytes` blocks,
+// which are dag-json's special sauce for schemafree links and for bytes.
 
 func Marshal(n ipld.Node, sink shared.TokenSink) error {
+	return MarshalWithOptions(n, sink, EncodeOptions{})
+}
+
+// MarshalWithOptions is Marshal, with options.
+// Only EncodeOptions.SortKeys affects the tokens emitted;
+// the whitespace options are for the json encoder (see EncodeWithOptions).
+func MarshalWithOptions(n ipld.Node, sink shared.TokenSink, opts EncodeOptions) error {
+	return marshal(n, sink, opts)
+}
+
+func marshal(n ipld.Node, sink shared.TokenSink, opts EncodeOptions) error {
 	var tk tok.Token
 	switch n.ReprKind() {
 	case ipld.ReprKind_Invalid:
@@ -31,6 +44,14 @@ func Marshal(n ipld.Node, sink shared.TokenSink) error {
 			return err
 		}
 		// Emit map contents (and recurse).
+		if opts.SortKeys {
+			if err := marshalSortedEntries(n, sink, opts); err != nil {
+				return err
+			}
+			tk.Type = tok.TMapClose
+			_, err := sink.Step(&tk)
+			return err
+		}
 		for itr := n.MapIterator(); !itr.Done(); {
 			k, v, err := itr.Next()
 			if err != nil {
@@ -44,7 +65,7 @@ func Marshal(n ipld.Node, sink shared.TokenSink) error {
 			if _, err := sink.Step(&tk); err != nil {
 				return err
 			}
-			if err := Marshal(v, sink); err != nil {
+			if err := marshal(v, sink, opts); err != nil {
 				return err
 			}
 		}
@@ -66,7 +87,7 @@ func Marshal(n ipld.Node, sink shared.TokenSink) error {
 			if err != nil {
 				return err
 			}
-			if err := Marshal(v, sink); err != nil {
+			if err := marshal(v, sink, opts); err != nil {
 				return err
 			}
 		}
@@ -115,10 +136,39 @@ func Marshal(n ipld.Node, sink shared.TokenSink) error {
 		if err != nil {
 			return err
 		}
-		tk.Type = tok.TBytes
-		tk.Bytes = v
-		_, err = sink.Step(&tk)
-		return err
+		// Precisely seven tokens to emit:
+		//  bytes are written as `{"/":{"bytes":"<base64>"}}`.
+		tk.Type = tok.TMapOpen
+		tk.Length = 1
+		if _, err = sink.Step(&tk); err != nil {
+			return err
+		}
+		tk.Type = tok.TString
+		tk.Str = "/"
+		if _, err = sink.Step(&tk); err != nil {
+			return err
+		}
+		tk.Type = tok.TMapOpen
+		if _, err = sink.Step(&tk); err != nil {
+			return err
+		}
+		tk.Type = tok.TString
+		tk.Str = "bytes"
+		if _, err = sink.Step(&tk); err != nil {
+			return err
+		}
+		tk.Str = base64.RawStdEncoding.EncodeToString(v)
+		if _, err = sink.Step(&tk); err != nil {
+			return err
+		}
+		tk.Type = tok.TMapClose
+		if _, err = sink.Step(&tk); err != nil {
+			return err
+		}
+		if _, err = sink.Step(&tk); err != nil {
+			return err
+		}
+		return nil
 	case ipld.ReprKind_Link:
 		v, err := n.AsLink()
 		if err != nil {
@@ -153,3 +203,39 @@ func Marshal(n ipld.Node, sink shared.TokenSink) error {
 		panic("unreachable")
 	}
 }
+
+// marshalSortedEntries emits the entries of a map with the keys sorted
+// bytewise, as they are in dag-json's canonical form.
+func marshalSortedEntries(n ipld.Node, sink shared.TokenSink, opts EncodeOptions) error {
+	type entry struct {
+		k string
+		v ipld.Node
+	}
+	entries := make([]entry, 0, n.Length())
+	for itr := n.MapIterator(); !itr.Done(); {
+		k, v, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		ks, err := k.AsString()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{ks, v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].k < entries[j].k
+	})
+	var tk tok.Token
+	for _, e := range entries {
+		tk.Type = tok.TString
+		tk.Str = e.k
+		if _, err := sink.Step(&tk); err != nil {
+			return err
+		}
+		if err := marshal(e.v, sink, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
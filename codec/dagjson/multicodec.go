@@ -7,6 +7,7 @@ import (
 	"github.com/polydawn/refmt/json"
 
 	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec"
 	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 )
 
@@ -21,9 +22,24 @@ func init() {
 }
 
 func Decoder(na ipld.NodeAssembler, r io.Reader) error {
+	return DecodeWithOptions(na, r, DecodeOptions{})
+}
+
+// DecodeOptions configures DecodeWithOptions.
+// The zero value gives the same behavior as Decoder.
+type DecodeOptions struct {
+	// Limits bounds the resources decoding may use; see codec.DecodeLimits.
+	Limits codec.DecodeLimits
+}
+
+// DecodeWithOptions is Decoder, with options.
+//
+// To have cidlink use particular options, register a decoder which uses
+// them (see DecodeOptions.Decoder).
+func DecodeWithOptions(na ipld.NodeAssembler, r io.Reader, opts DecodeOptions) error {
 	// Shell out directly to generic builder path.
 	//  (There's not really any fastpaths of note for json.)
-	err := Unmarshal(na, json.NewDecoder(r))
+	err := UnmarshalWithLimits(na, json.NewDecoder(r), opts.Limits)
 	if err != nil {
 		return err
 	}
@@ -52,12 +68,63 @@ func Decoder(na ipld.NodeAssembler, r io.Reader) error {
 	return err
 }
 
+// Decoder returns a cidlink.MulticodecDecoder which decodes with these options.
+// Registering it makes cidlink.Link.Load use the options for every dag-json block:
+//
+//	cidlink.RegisterMulticodecDecoder(0x0129, dagjson.DecodeOptions{
+//		Limits: codec.DecodeLimits{MaxDepth: 256, MaxAllocation: 1 << 20},
+//	}.Decoder())
+func (opts DecodeOptions) Decoder() cidlink.MulticodecDecoder {
+	return func(na ipld.NodeAssembler, r io.Reader) error {
+		return DecodeWithOptions(na, r, opts)
+	}
+}
+
 func Encoder(n ipld.Node, w io.Writer) error {
-	// Shell out directly to generic inspection path.
-	//  (There's not really any fastpaths of note for json.)
-	// Write another function if you need to tune encoding options about whitespace.
-	return Marshal(n, json.NewEncoder(w, json.EncodeOptions{
+	return EncodeWithOptions(n, w, EncodeOptions{
 		Line:   []byte{'\n'},
 		Indent: []byte{'\t'},
-	}))
+	})
+}
+
+// EncodeOptions holds the options for encoding dag-json.
+// The zero value gives compact output (all on one line), with map entries
+// in the order the map iterates them; Encoder instead puts each entry on
+// its own line, indented with tabs.
+type EncodeOptions struct {
+	// Line is written after each map entry or list element (and after the
+	// opening of a map or list), e.g. "\n".  If empty, output is on one line.
+	Line []byte
+
+	// Indent is written once per level of nesting at the start of each line,
+	// e.g. "\t" or "  ".  It's only used if Line is set.
+	Indent []byte
+
+	// SortKeys emits map entries with their keys sorted bytewise,
+	// rather than in the order the map iterates them.
+	// This makes the output deterministic for any map implementation.
+	SortKeys bool
+}
+
+// EncodeWithOptions is Encoder, with options.
+//
+// To have cidlink use particular options, register an encoder which uses
+// them (see EncodeOptions.Encoder).
+func EncodeWithOptions(n ipld.Node, w io.Writer, opts EncodeOptions) error {
+	// Shell out directly to generic inspection path.
+	//  (There's not really any fastpaths of note for json.)
+	return MarshalWithOptions(n, json.NewEncoder(w, json.EncodeOptions{
+		Line:   opts.Line,
+		Indent: opts.Indent,
+	}), opts)
+}
+
+// Encoder returns a cidlink.MulticodecEncoder which encodes with these options.
+// Registering it makes cidlink.LinkBuilder use the options for every dag-json block:
+//
+//	cidlink.RegisterMulticodecEncoder(0x0129, dagjson.EncodeOptions{SortKeys: true}.Encoder())
+func (opts EncodeOptions) Encoder() cidlink.MulticodecEncoder {
+	return func(n ipld.Node, w io.Writer) error {
+		return EncodeWithOptions(n, w, opts)
+	}
 }
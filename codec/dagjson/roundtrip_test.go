@@ -2,10 +2,13 @@ package dagjson
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
+	"github.com/polydawn/refmt/json"
 	. "github.com/warpfork/go-wish"
 
+	ipld "github.com/ipld/go-ipld-prime"
 	"github.com/ipld/go-ipld-prime/fluent"
 	basicnode "github.com/ipld/go-ipld-prime/node/basic"
 )
@@ -78,3 +81,94 @@ func TestRoundtripScalar(t *testing.T) {
 		Wish(t, nb.Build(), ShouldEqual, simple)
 	})
 }
+
+func TestRoundtripBytes(t *testing.T) {
+	withBytes := fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+		na.AssembleEntry("data").AssignBytes([]byte("hello\x00world"))
+		na.AssembleEntry("empty").AssignBytes([]byte{})
+	})
+	serial := `{
+	"data": {
+		"/": {
+			"bytes": "aGVsbG8Ad29ybGQ"
+		}
+	},
+	"empty": {
+		"/": {
+			"bytes": ""
+		}
+	}
+}
+`
+	t.Run("encoding", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Encoder(withBytes, &buf)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, buf.String(), ShouldEqual, serial)
+	})
+	t.Run("decoding", func(t *testing.T) {
+		nb := basicnode.Style__Map{}.NewBuilder()
+		err := Decoder(nb, bytes.NewBufferString(serial))
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, nb.Build(), ShouldEqual, withBytes)
+	})
+	t.Run("decoding compact form", func(t *testing.T) {
+		nb := basicnode.Style__Any{}.NewBuilder()
+		err := Decoder(nb, bytes.NewBufferString(`[{"/":{"bytes":"AQID"}},1]`))
+		Require(t, err, ShouldEqual, nil)
+		v, _ := nb.Build().LookupIndex(0)
+		Wish(t, v.ReprKind(), ShouldEqual, ipld.ReprKind_Bytes)
+		b, _ := v.AsBytes()
+		Wish(t, b, ShouldEqual, []byte{1, 2, 3})
+	})
+	t.Run("near misses decode as maps", func(t *testing.T) {
+		for _, s := range []string{
+			`{"/":{"bytes":1}}`,
+			`{"/":{"bytes":"AQID","x":1}}`,
+			`{"/":{"bytes":"AQID"},"x":1}`,
+			`{"/":{"x":[1,{"y":2}]}}`,
+			`{"/":{"/":{"bytes":"AQID"}}}`,
+		} {
+			nb := basicnode.Style__Any{}.NewBuilder()
+			err := Decoder(nb, bytes.NewBufferString(s))
+			Require(t, err, ShouldEqual, nil)
+			Wish(t, nb.Build().ReprKind(), ShouldEqual, ipld.ReprKind_Map)
+			var buf bytes.Buffer
+			err = Marshal(nb.Build(), json.NewEncoder(&buf, json.EncodeOptions{}))
+			Require(t, err, ShouldEqual, nil)
+			Wish(t, buf.String(), ShouldEqual, s)
+		}
+	})
+	t.Run("invalid base64 is rejected", func(t *testing.T) {
+		nb := basicnode.Style__Any{}.NewBuilder()
+		err := Decoder(nb, bytes.NewBufferString(`{"/":{"bytes":"!!"}}`))
+		Require(t, err != nil, ShouldEqual, true)
+	})
+}
+
+func TestEncodeOptions(t *testing.T) {
+	encode := func(opts EncodeOptions) string {
+		var buf bytes.Buffer
+		err := EncodeWithOptions(n, &buf, opts)
+		Require(t, err, ShouldEqual, nil)
+		return buf.String()
+	}
+	t.Run("zero value is compact", func(t *testing.T) {
+		Wish(t, encode(EncodeOptions{}), ShouldEqual,
+			`{"plain":"olde string","map":{"one":1,"two":2},"list":["three","four"],"nested":{"deeper":["things"]}}`)
+	})
+	t.Run("indentation", func(t *testing.T) {
+		Wish(t, encode(EncodeOptions{Line: []byte{'\n'}, Indent: []byte("  ")}), ShouldEqual,
+			strings.Replace(serial, "\t", "  ", -1))
+	})
+	t.Run("sorted keys", func(t *testing.T) {
+		Wish(t, encode(EncodeOptions{SortKeys: true}), ShouldEqual,
+			`{"list":["three","four"],"map":{"one":1,"two":2},"nested":{"deeper":["things"]},"plain":"olde string"}`)
+	})
+	t.Run("encoder for cidlink", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := EncodeOptions{SortKeys: true}.Encoder()(n, &buf)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, buf.String(), ShouldEqual, encode(EncodeOptions{SortKeys: true}))
+	})
+}
@@ -1,6 +1,7 @@
 package dagjson
 
 import (
+	"encoding/base64"
 	"fmt"
 
 	cid "github.com/ipfs/go-cid"
@@ -8,19 +9,27 @@ import (
 	"github.com/polydawn/refmt/tok"
 
 	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec"
 	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
 )
 
 // This drifts pretty far from the general unmarshal in the parent package:
 //   - we know JSON never has length hints, so we ignore that field in tokens;
 //   - we know JSON never has tags, so we ignore that field as well;
-//   - we have dag-json's special sauce for detecting schemafree links
+//   - we have dag-json's special sauce for detecting schemafree links and bytes
 //      (and this unfortunately turns out to *significantly* convolute the first
 //       several steps of handling maps, because it necessitates peeking several
 //        tokens before deciding what kind of value to create).
 
 func Unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource) error {
-	var st unmarshalState
+	return UnmarshalWithLimits(na, tokSrc, codec.DecodeLimits{})
+}
+
+// UnmarshalWithLimits is Unmarshal, halting with a codec.ErrDecodeLimitExceeded
+// if the data exceeds any of the given limits.
+// (JSON never declares lengths up front, so lengths are checked as they're seen.)
+func UnmarshalWithLimits(na ipld.NodeAssembler, tokSrc shared.TokenSource, limits codec.DecodeLimits) error {
+	st := unmarshalState{budget: codec.NewDecodeBudget(limits)}
 	done, err := tokSrc.Step(&st.tk[0])
 	if err != nil {
 		return err
@@ -32,86 +41,99 @@ func Unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource) error {
 }
 
 type unmarshalState struct {
-	tk    [4]tok.Token // mostly, only 0'th is used... but [1:4] are used during lookahead for links.
-	shift int          // how many times to slide something out of tk[1:4] instead of getting a new token.
+	tk    [7]tok.Token // mostly, only 0'th is used... but [1:7] are used during lookahead for links and bytes.
+	shift int          // how many tokens are buffered in tk[1:7], to slide out instead of getting a new token.
+
+	budget *codec.DecodeBudget // nil if there are no limits.
 }
 
 // step leaves a "new" token in tk[0],
-// taking account of an shift left by linkLookahead.
-// It's only necessary to use this when handling maps,
-// since the situations resulting in nonzero shift are otherwise unreachable.
+// taking account of any tokens buffered by linkLookahead.
 //
-// At most, 'step' will be shifting buffered tokens for:
+// At most, 'step' will be shifting buffered tokens for the prefix of
+// the longest special form (bytes) which matched before something didn't:
 //   - the first map key
-//   - the first map value (which will be a string)
-//   - the second map key
-// and so (fortunately! whew!) we can do this in a fixed amount of memory,
-// since none of those states can reach a recursion.
+//   - the first map value (which may be a string, or a map)
+//   - the second map key (or the first key of that inner map)
+//   - and so on, up to six tokens.
+// Since the lookahead stops at the first token that doesn't fit, the
+// last buffered token is the only one which can begin a recursion;
+// and any lookahead made by that recursion just continues using the buffer,
+// so we can do this in a fixed amount of memory.
 func (st *unmarshalState) step(tokSrc shared.TokenSource) error {
-	switch st.shift {
-	case 0:
+	if st.shift == 0 {
 		_, err := tokSrc.Step(&st.tk[0])
 		return err
-	case 1:
-		st.tk[0] = st.tk[1]
-		st.shift--
-		return nil
-	case 2:
-		st.tk[0] = st.tk[1]
-		st.tk[1] = st.tk[2]
-		st.shift--
-		return nil
-	case 3:
-		st.tk[0] = st.tk[1]
-		st.tk[1] = st.tk[2]
-		st.tk[2] = st.tk[3]
-		st.shift--
-		return nil
-	default:
-		panic("unreachable")
+	}
+	copy(st.tk[:st.shift], st.tk[1:st.shift+1])
+	st.shift--
+	return nil
+}
+
+// peek returns the i'th token after tk[0], reading more tokens into the
+// buffer if necessary.  Tokens must be peeked in order.
+func (st *unmarshalState) peek(tokSrc shared.TokenSource, i int) (*tok.Token, error) {
+	if i > st.shift {
+		if _, err := tokSrc.Step(&st.tk[i]); err != nil {
+			return nil, err
+		}
+		st.shift = i
+	}
+	return &st.tk[i], nil
+}
+
+// discard drops the first n buffered tokens, after a special form has been consumed.
+func (st *unmarshalState) discard(tokSrc shared.TokenSource, n int) {
+	for ; n > 0; n-- {
+		st.step(tokSrc) // can't error; the tokens are buffered.
 	}
 }
 
 // linkLookahead is called after receiving a TMapOpen token;
-// when it returns, we will have either created a link, OR
+// when it returns, we will have either created a link (or bytes), OR
 // it's not a link, and the caller should proceed to start a map
 // and while using st.step to ensure the peeked tokens are handled, OR
 // in case of error, the error should just rise.
-// If the bool return is true, we got a link, and you should not
+// If the bool return is true, we got a link (or bytes), and you should not
 // continue to attempt to build a map.
+//
+// The forms recognized are `{"/":"<cid>"}` for links,
+// and `{"/":{"bytes":"<base64>"}}` for bytes.
 func (st *unmarshalState) linkLookahead(na ipld.NodeAssembler, tokSrc shared.TokenSource) (bool, error) {
 	// Peek next token.  If it's a "/" string, link is still a possibility
-	_, err := tokSrc.Step(&st.tk[1])
+	tk, err := st.peek(tokSrc, 1)
 	if err != nil {
 		return false, err
 	}
-	if st.tk[1].Type != tok.TString {
-		st.shift = 1
-		return false, nil
-	}
-	if st.tk[1].Str != "/" {
-		st.shift = 1
+	if tk.Type != tok.TString || tk.Str != "/" {
 		return false, nil
 	}
 	// Peek next token.  If it's a string, link is still a possibility.
-	//  We won't try to parse it as a CID until we're sure it's the only thing in the map, though.
-	_, err = tokSrc.Step(&st.tk[2])
+	//  If it's a map open, bytes are still a possibility.
+	//  We won't try to parse either until we're sure it's the only thing in the map, though.
+	tk, err = st.peek(tokSrc, 2)
 	if err != nil {
 		return false, err
 	}
-	if st.tk[2].Type != tok.TString {
-		st.shift = 2
+	switch tk.Type {
+	case tok.TString:
+		return st.linkLookaheadCid(na, tokSrc)
+	case tok.TMapOpen:
+		return st.linkLookaheadBytes(na, tokSrc)
+	default:
 		return false, nil
 	}
+}
+
+func (st *unmarshalState) linkLookaheadCid(na ipld.NodeAssembler, tokSrc shared.TokenSource) (bool, error) {
 	// Peek next token.  If it's map close, we've got a link!
 	//  (Otherwise it had better be a string, because another map key is the
 	//   only other valid transition here... but we'll leave that check to the caller.
-	_, err = tokSrc.Step(&st.tk[3])
+	tk, err := st.peek(tokSrc, 3)
 	if err != nil {
 		return false, err
 	}
-	if st.tk[3].Type != tok.TMapClose {
-		st.shift = 3
+	if tk.Type != tok.TMapClose {
 		return false, nil
 	}
 	// Okay, we made it -- this looks like a link.  Parse it.
@@ -120,11 +142,55 @@ func (st *unmarshalState) linkLookahead(na ipld.NodeAssembler, tokSrc shared.Tok
 	if err != nil {
 		return false, err
 	}
+	st.discard(tokSrc, 3)
 	if err := na.AssignLink(cidlink.Link{elCid}); err != nil {
 		return false, err
 	}
 	return true, nil
+}
 
+func (st *unmarshalState) linkLookaheadBytes(na ipld.NodeAssembler, tokSrc shared.TokenSource) (bool, error) {
+	// Peek the inner map's key, which must be "bytes";
+	//  then its value, which must be a string;
+	//  then the closes of both maps.
+	tk, err := st.peek(tokSrc, 3)
+	if err != nil {
+		return false, err
+	}
+	if tk.Type != tok.TString || tk.Str != "bytes" {
+		return false, nil
+	}
+	tk, err = st.peek(tokSrc, 4)
+	if err != nil {
+		return false, err
+	}
+	if tk.Type != tok.TString {
+		return false, nil
+	}
+	for i := 5; i <= 6; i++ {
+		tk, err = st.peek(tokSrc, i)
+		if err != nil {
+			return false, err
+		}
+		if tk.Type != tok.TMapClose {
+			return false, nil
+		}
+	}
+	// Okay, we made it -- this looks like bytes.  Decode them.
+	//  If they *don't* decode as base64, we treat this as an error.
+	//  (The unpadded standard alphabet is used, as in the dag-json spec.)
+	if err := st.budget.Alloc(base64.RawStdEncoding.DecodedLen(len(st.tk[4].Str))); err != nil {
+		return false, err
+	}
+	b, err := base64.RawStdEncoding.DecodeString(st.tk[4].Str)
+	if err != nil {
+		return false, fmt.Errorf("invalid base64 in dag-json bytes: %s", err)
+	}
+	st.discard(tokSrc, 6)
+	if err := na.AssignBytes(b); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // starts with the first token already primed.  Necessary to get recursion
@@ -144,10 +210,15 @@ func (st *unmarshalState) unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSo
 		}
 
 		// Okay, now back to regularly scheduled map logic.
+		if err := st.budget.Enter(-1); err != nil {
+			return err
+		}
+		defer st.budget.Leave()
 		ma, err := na.BeginMap(-1)
 		if err != nil {
 			return err
 		}
+		observedLen := 0
 		for {
 			err := st.step(tokSrc) // shift next token into slot 0.
 			if err != nil {        // return in error if next token unreadable
@@ -161,6 +232,13 @@ func (st *unmarshalState) unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSo
 			default:
 				return fmt.Errorf("unexpected %s token while expecting map key", st.tk[0].Type)
 			}
+			observedLen++
+			if err := st.budget.Entry(observedLen); err != nil {
+				return err
+			}
+			if err := st.budget.Alloc(len(st.tk[0].Str)); err != nil {
+				return err
+			}
 			mva, err := ma.AssembleEntry(st.tk[0].Str)
 			if err != nil { // return in error if the key was rejected
 				return err
@@ -178,12 +256,17 @@ func (st *unmarshalState) unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSo
 	case tok.TMapClose:
 		return fmt.Errorf("unexpected mapClose token")
 	case tok.TArrOpen:
+		if err := st.budget.Enter(-1); err != nil {
+			return err
+		}
+		defer st.budget.Leave()
 		la, err := na.BeginList(-1)
 		if err != nil {
 			return err
 		}
+		observedLen := 0
 		for {
-			_, err := tokSrc.Step(&st.tk[0])
+			err := st.step(tokSrc) // shift next token into slot 0.
 			if err != nil {
 				return err
 			}
@@ -191,6 +274,10 @@ func (st *unmarshalState) unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSo
 			case tok.TArrClose:
 				return la.Finish()
 			default:
+				observedLen++
+				if err := st.budget.Entry(observedLen); err != nil {
+					return err
+				}
 				err := st.unmarshal(la.AssembleValue(), tokSrc)
 				if err != nil { // return in error if some part of the recursion errored
 					return err
@@ -202,8 +289,14 @@ func (st *unmarshalState) unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSo
 	case tok.TNull:
 		return na.AssignNull()
 	case tok.TString:
+		if err := st.budget.Alloc(len(st.tk[0].Str)); err != nil {
+			return err
+		}
 		return na.AssignString(st.tk[0].Str)
 	case tok.TBytes:
+		if err := st.budget.Alloc(len(st.tk[0].Bytes)); err != nil {
+			return err
+		}
 		return na.AssignBytes(st.tk[0].Bytes)
 	case tok.TBool:
 		return na.AssignBool(st.tk[0].Bool)
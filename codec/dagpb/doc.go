@@ -0,0 +1,32 @@
+// Package dagpb implements the dag-pb codec (multicodec 0x70), which is the
+// protobuf format used by UnixFS, and by much of the data in IPFS.
+//
+// Unlike dag-cbor and dag-json, dag-pb can't hold arbitrary data:
+// every block is a node of the following shape (as an IPLD Schema):
+//
+//	type PBNode struct {
+//		Links [PBLink]
+//		Data optional Bytes
+//	}
+//
+//	type PBLink struct {
+//		Hash Link
+//		Name optional String
+//		Tsize optional Int
+//	}
+//
+// Decoding yields maps and lists of exactly that shape; encoding accepts
+// only nodes of that shape (absent optional fields are simply absent from the map).
+//
+// Both directions are strict, following the dag-pb specification:
+// fields are encoded in the canonical order (Links before Data; and within
+// each link, Hash, Name, then Tsize), and any other encoding -- fields out of
+// order or repeated, unknown fields, non-minimal varints -- is rejected when
+// decoding.  So a block which decodes will re-encode to exactly the same bytes,
+// and the bytes are the same as produced by other implementations.
+// (Links are kept in the order given; the specification recommends sorting
+// them by Name, but that's up to whatever builds the node.)
+//
+// See https://github.com/ipld/specs/blob/master/block-layer/codecs/dag-pb.md
+// for the specification.
+package dagpb
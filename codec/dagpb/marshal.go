@@ -0,0 +1,133 @@
+package dagpb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// Protobuf field keys: the field number, shifted, plus the wire type
+// (0 for varints; 2 for length-delimited bytes).
+const (
+	keyNode_Data  = 1<<3 | 2
+	keyNode_Links = 2<<3 | 2
+	keyLink_Hash  = 1<<3 | 2
+	keyLink_Name  = 2<<3 | 2
+	keyLink_Tsize = 3<<3 | 0
+)
+
+// Marshal encodes a node of the PBNode shape (see the package docs) as dag-pb.
+func Marshal(n ipld.Node, w io.Writer) error {
+	if n.ReprKind() != ipld.ReprKind_Map {
+		return fmt.Errorf("dag-pb encoding rejected: node must be a map, not %s", n.ReprKind())
+	}
+	if err := checkKeys(n, "PBNode", "Links", "Data"); err != nil {
+		return fmt.Errorf("dag-pb encoding rejected: %s", err)
+	}
+	links, err := n.LookupString("Links")
+	if err != nil {
+		return fmt.Errorf("dag-pb encoding rejected: PBNode must have Links")
+	}
+	if links.ReprKind() != ipld.ReprKind_List {
+		return fmt.Errorf("dag-pb encoding rejected: PBNode Links must be a list, not %s", links.ReprKind())
+	}
+	var buf []byte
+	for itr := links.ListIterator(); !itr.Done(); {
+		idx, lnk, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		lnkBuf, err := marshalLink(lnk)
+		if err != nil {
+			return fmt.Errorf("dag-pb encoding rejected: Links[%d]: %s", idx, err)
+		}
+		buf = appendBytesField(buf, keyNode_Links, lnkBuf)
+	}
+	if data, err := n.LookupString("Data"); err == nil {
+		b, err := data.AsBytes()
+		if err != nil {
+			return fmt.Errorf("dag-pb encoding rejected: PBNode Data must be bytes, not %s", data.ReprKind())
+		}
+		buf = appendBytesField(buf, keyNode_Data, b)
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func marshalLink(n ipld.Node) ([]byte, error) {
+	if n.ReprKind() != ipld.ReprKind_Map {
+		return nil, fmt.Errorf("PBLink must be a map, not %s", n.ReprKind())
+	}
+	if err := checkKeys(n, "PBLink", "Hash", "Name", "Tsize"); err != nil {
+		return nil, err
+	}
+	var buf []byte
+	hash, err := n.LookupString("Hash")
+	if err != nil {
+		return nil, fmt.Errorf("PBLink must have Hash")
+	}
+	lnk, err := hash.AsLink()
+	if err != nil {
+		return nil, fmt.Errorf("PBLink Hash must be a link, not %s", hash.ReprKind())
+	}
+	cl, ok := lnk.(cidlink.Link)
+	if !ok {
+		return nil, fmt.Errorf("PBLink Hash must be a cidlink.Link, not %T", lnk)
+	}
+	buf = appendBytesField(buf, keyLink_Hash, cl.Bytes())
+	if name, err := n.LookupString("Name"); err == nil {
+		s, err := name.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("PBLink Name must be a string, not %s", name.ReprKind())
+		}
+		buf = appendBytesField(buf, keyLink_Name, []byte(s))
+	}
+	if tsize, err := n.LookupString("Tsize"); err == nil {
+		i, err := tsize.AsInt()
+		if err != nil {
+			return nil, fmt.Errorf("PBLink Tsize must be an int, not %s", tsize.ReprKind())
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("PBLink Tsize must not be negative")
+		}
+		buf = appendVarint(buf, keyLink_Tsize)
+		buf = appendVarint(buf, uint64(i))
+	}
+	return buf, nil
+}
+
+// checkKeys rejects maps with any keys other than those allowed.
+func checkKeys(n ipld.Node, typ string, allowed ...string) error {
+	for itr := n.MapIterator(); !itr.Done(); {
+		k, _, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		ks, err := k.AsString()
+		if err != nil {
+			return err
+		}
+		known := false
+		for _, a := range allowed {
+			known = known || ks == a
+		}
+		if !known {
+			return fmt.Errorf("%s has unknown field %q", typ, ks)
+		}
+	}
+	return nil
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func appendBytesField(buf []byte, key uint64, b []byte) []byte {
+	buf = appendVarint(buf, key)
+	buf = appendVarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
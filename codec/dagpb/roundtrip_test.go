@@ -0,0 +1,127 @@
+package dagpb
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	cid "github.com/ipfs/go-cid"
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+// The empty UnixFS directory: a well-known block, for checking interoperability.
+var emptyDir = fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+	na.AssembleEntry("Links").CreateList(0, func(na fluent.ListAssembler) {})
+	na.AssembleEntry("Data").AssignBytes([]byte{0x08, 0x01})
+})
+var emptyDirSerial = "\x0a\x02\x08\x01"
+var emptyDirCid = "QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn"
+
+var linkTarget, _ = cid.Decode(emptyDirCid)
+var n = fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+	na.AssembleEntry("Links").CreateList(2, func(na fluent.ListAssembler) {
+		na.AssembleValue().CreateMap(3, func(na fluent.MapAssembler) {
+			na.AssembleEntry("Hash").AssignLink(cidlink.Link{Cid: linkTarget})
+			na.AssembleEntry("Name").AssignString("dir")
+			na.AssembleEntry("Tsize").AssignInt(4)
+		})
+		na.AssembleValue().CreateMap(1, func(na fluent.MapAssembler) {
+			na.AssembleEntry("Hash").AssignLink(cidlink.Link{Cid: linkTarget})
+		})
+	})
+	na.AssembleEntry("Data").AssignBytes([]byte("hi"))
+})
+var serial = "\x12\x2b" + "\x0a\x22" + string(linkTarget.Bytes()) + "\x12\x03dir" + "\x18\x04" +
+	"\x12\x24" + "\x0a\x22" + string(linkTarget.Bytes()) +
+	"\x0a\x02hi"
+
+func TestRoundtrip(t *testing.T) {
+	t.Run("encoding", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Encoder(n, &buf)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, hex.EncodeToString(buf.Bytes()), ShouldEqual, hex.EncodeToString([]byte(serial)))
+	})
+	t.Run("decoding", func(t *testing.T) {
+		nb := basicnode.Style__Map{}.NewBuilder()
+		err := Decoder(nb, bytes.NewBufferString(serial))
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, nb.Build(), ShouldEqual, n)
+	})
+	t.Run("well-known block should have the well-known CID", func(t *testing.T) {
+		nb := basicnode.Style__Map{}.NewBuilder()
+		err := Decoder(nb, bytes.NewBufferString(emptyDirSerial))
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, nb.Build(), ShouldEqual, emptyDir)
+
+		lb := cidlink.LinkBuilder{Prefix: cid.Prefix{
+			Version:  0,
+			Codec:    0x70,
+			MhType:   0x12,
+			MhLength: 32,
+		}}
+		var buf bytes.Buffer
+		lnk, err := lb.Build(context.Background(), ipld.LinkContext{}, emptyDir,
+			func(ipld.LinkContext) (io.Writer, ipld.StoreCommitter, error) {
+				return &buf, func(lnk ipld.Link) error { return nil }, nil
+			},
+		)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, buf.String(), ShouldEqual, emptyDirSerial)
+		Wish(t, lnk.String(), ShouldEqual, emptyDirCid)
+	})
+}
+
+func TestRejection(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		serial string
+		err    string
+	}{
+		{"data before links", "\x0a\x00" + "\x12\x24\x0a\x22" + string(linkTarget.Bytes()),
+			"dag-pb decoding rejected at offset 2: unexpected field after PBNode Data"},
+		{"unknown field", "\x1a\x00",
+			"dag-pb decoding rejected at offset 0: unexpected field key 0x1a in PBNode"},
+		{"non-minimal varint", "\x0a\x82\x00",
+			"dag-pb decoding rejected at offset 1: varint is not minimally encoded"},
+		{"truncated", "\x0a\x05hi",
+			"dag-pb decoding rejected at offset 2: length 5 exceeds the remaining input"},
+		{"link name before hash", "\x12\x05\x12\x03dir",
+			"dag-pb decoding rejected at offset 7: PBLink must begin with Hash"},
+		{"link fields repeated", "\x12\x48" + "\x0a\x22" + string(linkTarget.Bytes()) + "\x0a\x22" + string(linkTarget.Bytes()),
+			"dag-pb decoding rejected at offset 38: unexpected field key 0xa in PBLink: out of order or repeated"},
+		{"link without hash", "\x12\x00",
+			"dag-pb decoding rejected at offset 2: PBLink must have Hash"},
+		{"link with invalid hash", "\x12\x03\x0a\x01\x01",
+			"dag-pb decoding rejected at offset 4: PBLink Hash is not a valid CID"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			nb := basicnode.Style__Map{}.NewBuilder()
+			err := Decoder(nb, bytes.NewBufferString(tc.serial))
+			Require(t, err != nil, ShouldEqual, true)
+			Wish(t, err.Error(), ShouldEqual, tc.err)
+		})
+	}
+	t.Run("encoding unknown fields", func(t *testing.T) {
+		bad := fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+			na.AssembleEntry("Links").CreateList(0, func(na fluent.ListAssembler) {})
+			na.AssembleEntry("Extra").AssignBool(true)
+		})
+		err := Encoder(bad, &bytes.Buffer{})
+		Wish(t, err.Error(), ShouldEqual, `dag-pb encoding rejected: PBNode has unknown field "Extra"`)
+	})
+	t.Run("encoding without links", func(t *testing.T) {
+		bad := fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry("Data").AssignBytes(nil)
+		})
+		err := Encoder(bad, &bytes.Buffer{})
+		Wish(t, err.Error(), ShouldEqual, `dag-pb encoding rejected: PBNode must have Links`)
+	})
+}
@@ -0,0 +1,259 @@
+package dagpb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	cid "github.com/ipfs/go-cid"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// pbNode and pbLink hold a decoded block, before it's assembled into Nodes.
+// (Absent optional fields are nil.)
+type pbNode struct {
+	links []pbLink
+	data  []byte
+}
+
+type pbLink struct {
+	hash  cid.Cid
+	name  *string
+	tsize *uint64
+}
+
+// Unmarshal decodes dag-pb, assembling a node of the PBNode shape (see the package docs).
+// Anything but the canonical encoding is rejected.
+func Unmarshal(na ipld.NodeAssembler, r io.Reader) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	node, err := unmarshalNode(buf)
+	if err != nil {
+		return err
+	}
+	return node.assemble(na)
+}
+
+// decoder reads protobuf fields from part of a buffer (up to end),
+// tracking the offset in the whole buffer for errors.
+type decoder struct {
+	buf []byte
+	pos int
+	end int
+}
+
+func (d *decoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("dag-pb decoding rejected at offset %d: %s", d.pos, fmt.Sprintf(format, args...))
+}
+
+func (d *decoder) done() bool {
+	return d.pos >= d.end
+}
+
+func (d *decoder) varint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf[d.pos:d.end])
+	if n == 0 {
+		return 0, d.errorf("unexpected end of input")
+	}
+	if n < 0 {
+		return 0, d.errorf("varint overflows 64 bits")
+	}
+	if n > 1 && d.buf[d.pos+n-1] == 0 {
+		return 0, d.errorf("varint is not minimally encoded")
+	}
+	d.pos += n
+	return v, nil
+}
+
+// bytes reads a length-delimited field, returning a decoder for its content.
+func (d *decoder) bytes() (*decoder, error) {
+	length, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(d.end-d.pos) {
+		return nil, d.errorf("length %d exceeds the remaining input", length)
+	}
+	content := &decoder{d.buf, d.pos, d.pos + int(length)}
+	d.pos = content.end
+	return content, nil
+}
+
+func (d *decoder) content() []byte {
+	return d.buf[d.pos:d.end]
+}
+
+func unmarshalNode(buf []byte) (pbNode, error) {
+	var node pbNode
+	d := &decoder{buf, 0, len(buf)}
+	for !d.done() {
+		keyPos := d.pos
+		key, err := d.varint()
+		if err != nil {
+			return node, err
+		}
+		if node.data != nil {
+			d.pos = keyPos
+			return node, d.errorf("unexpected field after PBNode Data")
+		}
+		switch key {
+		case keyNode_Links:
+			ld, err := d.bytes()
+			if err != nil {
+				return node, err
+			}
+			lnk, err := unmarshalLink(ld)
+			if err != nil {
+				return node, err
+			}
+			node.links = append(node.links, lnk)
+		case keyNode_Data:
+			dd, err := d.bytes()
+			if err != nil {
+				return node, err
+			}
+			node.data = dd.content()
+		default:
+			d.pos = keyPos
+			return node, d.errorf("unexpected field key 0x%x in PBNode", key)
+		}
+	}
+	return node, nil
+}
+
+func unmarshalLink(d *decoder) (pbLink, error) {
+	var lnk pbLink
+	// Fields must be in order, and each at most once; so track the last seen.
+	var last uint64
+	for !d.done() {
+		keyPos := d.pos
+		key, err := d.varint()
+		if err != nil {
+			return lnk, err
+		}
+		switch key {
+		case keyLink_Hash, keyLink_Name, keyLink_Tsize:
+			if key>>3 <= last>>3 {
+				d.pos = keyPos
+				return lnk, d.errorf("unexpected field key 0x%x in PBLink: out of order or repeated", key)
+			}
+			last = key
+		default:
+			d.pos = keyPos
+			return lnk, d.errorf("unexpected field key 0x%x in PBLink", key)
+		}
+		switch key {
+		case keyLink_Hash:
+			hd, err := d.bytes()
+			if err != nil {
+				return lnk, err
+			}
+			n, c, err := cid.CidFromBytes(hd.content())
+			if err != nil || n != len(hd.content()) {
+				return lnk, hd.errorf("PBLink Hash is not a valid CID")
+			}
+			lnk.hash = c
+		case keyLink_Name:
+			nd, err := d.bytes()
+			if err != nil {
+				return lnk, err
+			}
+			name := string(nd.content())
+			lnk.name = &name
+		case keyLink_Tsize:
+			tsize, err := d.varint()
+			if err != nil {
+				return lnk, err
+			}
+			if tsize > math.MaxInt64 {
+				return lnk, d.errorf("PBLink Tsize %d is too large", tsize)
+			}
+			lnk.tsize = &tsize
+		}
+		if lnk.hash == cid.Undef {
+			return lnk, d.errorf("PBLink must begin with Hash")
+		}
+	}
+	if lnk.hash == cid.Undef {
+		return lnk, d.errorf("PBLink must have Hash")
+	}
+	return lnk, nil
+}
+
+func (node pbNode) assemble(na ipld.NodeAssembler) error {
+	size := 1
+	if node.data != nil {
+		size = 2
+	}
+	ma, err := na.BeginMap(size)
+	if err != nil {
+		return err
+	}
+	if err := ma.AssembleKey().AssignString("Links"); err != nil {
+		return err
+	}
+	la, err := ma.AssembleValue().BeginList(len(node.links))
+	if err != nil {
+		return err
+	}
+	for _, lnk := range node.links {
+		if err := lnk.assemble(la.AssembleValue()); err != nil {
+			return err
+		}
+	}
+	if err := la.Finish(); err != nil {
+		return err
+	}
+	if node.data != nil {
+		if err := ma.AssembleKey().AssignString("Data"); err != nil {
+			return err
+		}
+		if err := ma.AssembleValue().AssignBytes(node.data); err != nil {
+			return err
+		}
+	}
+	return ma.Finish()
+}
+
+func (lnk pbLink) assemble(na ipld.NodeAssembler) error {
+	size := 1
+	if lnk.name != nil {
+		size++
+	}
+	if lnk.tsize != nil {
+		size++
+	}
+	ma, err := na.BeginMap(size)
+	if err != nil {
+		return err
+	}
+	if err := ma.AssembleKey().AssignString("Hash"); err != nil {
+		return err
+	}
+	if err := ma.AssembleValue().AssignLink(cidlink.Link{Cid: lnk.hash}); err != nil {
+		return err
+	}
+	if lnk.name != nil {
+		if err := ma.AssembleKey().AssignString("Name"); err != nil {
+			return err
+		}
+		if err := ma.AssembleValue().AssignString(*lnk.name); err != nil {
+			return err
+		}
+	}
+	if lnk.tsize != nil {
+		if err := ma.AssembleKey().AssignString("Tsize"); err != nil {
+			return err
+		}
+		if err := ma.AssembleValue().AssignInt(int(*lnk.tsize)); err != nil {
+			return err
+		}
+	}
+	return ma.Finish()
+}
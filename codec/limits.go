@@ -0,0 +1,136 @@
+package codec
+
+import (
+	"fmt"
+)
+
+// DecodeLimits bounds the resources a decoder may use, so that hostile
+// (or just broken) data can't exhaust memory or the stack.
+// A limit which is zero (or negative) is not enforced, so the zero value of
+// DecodeLimits enforces nothing.
+//
+// The token-based decoders here, and in the dagcbor and dagjson packages,
+// accept DecodeLimits (see UnmarshalWithLimits, and the DecodeOptions of each codec).
+// When a limit is exceeded, decoding halts with an ErrDecodeLimitExceeded.
+type DecodeLimits struct {
+	// MaxDepth is the maximum nesting of maps and lists.
+	// (A map at the top level is at depth 1; its values at depth 2; etc.)
+	MaxDepth int
+
+	// MaxLength is the maximum length of any single map or list.
+	// For formats which declare lengths up front (like CBOR), the declared
+	// length is checked before anything is allocated for it.
+	MaxLength int
+
+	// MaxAllocation is the maximum total size of everything decoded:
+	// the sum of the lengths of all the maps and lists (counted as for
+	// MaxLength), plus the lengths in bytes of all the strings (including
+	// map keys) and bytes.  It's not exactly the memory that will be used,
+	// which depends on the NodeAssembler.
+	//
+	// Strings and bytes are counted as the tokenizer yields them, which is
+	// after it has read (and so allocated) them.  So the memory used is bounded
+	// in proportion to MaxAllocation, plus the size of one string or bytes --
+	// which the tokenizer itself caps at 32 MiB, but which may be a declared
+	// size rather than the size of any actual data.  (See the codecs'
+	// DecodeOptions for ways to avoid this, e.g. dag-cbor's Strict.)
+	MaxAllocation int64
+}
+
+// DecodeLimitKind names one of the limits in DecodeLimits.
+type DecodeLimitKind string
+
+const (
+	DecodeLimitKind_Depth      DecodeLimitKind = "depth"      // DecodeLimits.MaxDepth.
+	DecodeLimitKind_Length     DecodeLimitKind = "length"     // DecodeLimits.MaxLength.
+	DecodeLimitKind_Allocation DecodeLimitKind = "allocation" // DecodeLimits.MaxAllocation.
+)
+
+// ErrDecodeLimitExceeded is returned by a decoder which was halted because
+// the data exceeded one of its DecodeLimits.
+type ErrDecodeLimitExceeded struct {
+	Limit DecodeLimitKind // Which limit was exceeded.
+	Max   int64           // The limit that was configured.
+	Got   int64           // The depth, length, or allocation that would have exceeded it.
+}
+
+func (e ErrDecodeLimitExceeded) Error() string {
+	return fmt.Sprintf("decode limit exceeded: %s of %d is over the limit of %d", e.Limit, e.Got, e.Max)
+}
+
+// DecodeBudget tracks how much of its DecodeLimits a single decode has used.
+// It's for use by codec implementations; see UnmarshalWithLimits for an example.
+//
+// A nil *DecodeBudget enforces no limits, and its methods are cheap,
+// so decoders can use one unconditionally.
+type DecodeBudget struct {
+	limits DecodeLimits
+	depth  int
+	alloc  int64
+}
+
+// NewDecodeBudget returns a DecodeBudget for the given limits,
+// or nil if they don't limit anything.
+func NewDecodeBudget(limits DecodeLimits) *DecodeBudget {
+	if limits == (DecodeLimits{}) {
+		return nil
+	}
+	return &DecodeBudget{limits: limits}
+}
+
+// Enter is called when starting to decode a map or list, with its length
+// (or -1 if the length isn't known in advance).  It checks the depth and the
+// length; and counts the length toward the allocation.
+// Each Enter must be paired with a Leave, unless it returned an error.
+func (b *DecodeBudget) Enter(length int) error {
+	if b == nil {
+		return nil
+	}
+	if b.limits.MaxDepth > 0 && b.depth+1 > b.limits.MaxDepth {
+		return ErrDecodeLimitExceeded{DecodeLimitKind_Depth, int64(b.limits.MaxDepth), int64(b.depth + 1)}
+	}
+	if length > 0 {
+		if b.limits.MaxLength > 0 && length > b.limits.MaxLength {
+			return ErrDecodeLimitExceeded{DecodeLimitKind_Length, int64(b.limits.MaxLength), int64(length)}
+		}
+		if err := b.Alloc(length); err != nil {
+			return err
+		}
+	}
+	b.depth++
+	return nil
+}
+
+// Entry is called for each entry of a map or list whose length wasn't known
+// in advance, with the number of entries so far (including this one).
+// It counts the entry toward the allocation, and checks the length.
+func (b *DecodeBudget) Entry(observedLength int) error {
+	if b == nil {
+		return nil
+	}
+	if b.limits.MaxLength > 0 && observedLength > b.limits.MaxLength {
+		return ErrDecodeLimitExceeded{DecodeLimitKind_Length, int64(b.limits.MaxLength), int64(observedLength)}
+	}
+	return b.Alloc(1)
+}
+
+// Leave is called when done decoding a map or list.
+func (b *DecodeBudget) Leave() {
+	if b == nil {
+		return
+	}
+	b.depth--
+}
+
+// Alloc counts the given size toward the allocation: for example, the
+// length of a string or bytes.
+func (b *DecodeBudget) Alloc(size int) error {
+	if b == nil {
+		return nil
+	}
+	b.alloc += int64(size)
+	if b.limits.MaxAllocation > 0 && b.alloc > b.limits.MaxAllocation {
+		return ErrDecodeLimitExceeded{DecodeLimitKind_Allocation, b.limits.MaxAllocation, b.alloc}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Package raw implements the raw codec (multicodec 0x55), in which a block is
+// just bytes: decoding yields a single Bytes node holding the whole block,
+// and only Bytes nodes can be encoded.
+//
+// This is typically used for the leaves of a DAG, such as the chunks of a
+// file, so that they can be loaded through cidlink like any other block.
+package raw
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+var (
+	_ cidlink.MulticodecDecoder = Decoder
+	_ cidlink.MulticodecEncoder = Encoder
+)
+
+func init() {
+	cidlink.RegisterMulticodecDecoder(0x55, Decoder)
+	cidlink.RegisterMulticodecEncoder(0x55, Encoder)
+}
+
+// Decoder reads the whole of the reader, and assigns it as bytes.
+func Decoder(na ipld.NodeAssembler, r io.Reader) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return na.AssignBytes(buf)
+}
+
+// Encoder writes the content of a Bytes node.  Nodes of any other kind are rejected.
+func Encoder(n ipld.Node, w io.Writer) error {
+	if n.ReprKind() != ipld.ReprKind_Bytes {
+		return fmt.Errorf("raw encoding rejected: node must be of kind Bytes, not %s", n.ReprKind())
+	}
+	buf, err := n.AsBytes()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
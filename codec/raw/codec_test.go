@@ -0,0 +1,67 @@
+package raw
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	cid "github.com/ipfs/go-cid"
+	ipld "github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+func TestRoundtrip(t *testing.T) {
+	n := basicnode.NewBytes([]byte("\x00\x01 some bytes \xff"))
+	t.Run("encoding", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Encoder(n, &buf)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, buf.String(), ShouldEqual, "\x00\x01 some bytes \xff")
+	})
+	t.Run("decoding", func(t *testing.T) {
+		nb := basicnode.Style__Any{}.NewBuilder()
+		err := Decoder(nb, bytes.NewBufferString("\x00\x01 some bytes \xff"))
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, nb.Build(), ShouldEqual, n)
+	})
+	t.Run("encoding other kinds should be rejected", func(t *testing.T) {
+		err := Encoder(basicnode.NewString("text"), &bytes.Buffer{})
+		Wish(t, err.Error(), ShouldEqual, "raw encoding rejected: node must be of kind Bytes, not String")
+	})
+	t.Run("links should load through cidlink", func(t *testing.T) {
+		lb := cidlink.LinkBuilder{Prefix: cid.Prefix{
+			Version:  1,
+			Codec:    0x55,
+			MhType:   0x12,
+			MhLength: 32,
+		}}
+		var buf bytes.Buffer
+		lnk, err := lb.Build(context.Background(), ipld.LinkContext{}, n,
+			func(ipld.LinkContext) (io.Writer, ipld.StoreCommitter, error) {
+				return &buf, func(lnk ipld.Link) error { return nil }, nil
+			},
+		)
+		Require(t, err, ShouldEqual, nil)
+		// The well-known CID of "hello world" tells us the raw encoding is exactly the bytes.
+		helloLnk, err := lb.Build(context.Background(), ipld.LinkContext{}, basicnode.NewBytes([]byte("hello world")),
+			func(ipld.LinkContext) (io.Writer, ipld.StoreCommitter, error) {
+				return &bytes.Buffer{}, func(lnk ipld.Link) error { return nil }, nil
+			},
+		)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, helloLnk.String(), ShouldEqual, "bafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5e")
+
+		nb := basicnode.Style__Any{}.NewBuilder()
+		err = lnk.Load(context.Background(), ipld.LinkContext{}, nb,
+			func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+				return bytes.NewBuffer(buf.Bytes()), nil
+			},
+		)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, nb.Build(), ShouldEqual, n)
+	})
+}
@@ -37,20 +37,31 @@ import (
 // (The dag-cbor and dag-json formats can be used if links are of CID
 // implementation and need to be decoded in a schemafree way.)
 func Unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource) error {
+	return UnmarshalWithLimits(na, tokSrc, DecodeLimits{})
+}
+
+// UnmarshalWithLimits is Unmarshal, halting with an ErrDecodeLimitExceeded
+// if the data exceeds any of the given limits.
+func UnmarshalWithLimits(na ipld.NodeAssembler, tokSrc shared.TokenSource, limits DecodeLimits) error {
 	var tk tok.Token
-	done, err := tokSrc.Step(&tk)
+	return unmarshalNext(na, tokSrc, &tk, NewDecodeBudget(limits))
+}
+
+// unmarshalNext steps to the next token, then unmarshals starting from it.
+func unmarshalNext(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token, budget *DecodeBudget) error {
+	done, err := tokSrc.Step(tk)
 	if err != nil {
 		return err
 	}
 	if done && !tk.Type.IsValue() {
 		return fmt.Errorf("unexpected eof")
 	}
-	return unmarshal(na, tokSrc, &tk)
+	return unmarshal(na, tokSrc, tk, budget)
 }
 
 // starts with the first token already primed.  Necessary to get recursion
 //  to flow right without a peek+unpeek system.
-func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token) error {
+func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token, budget *DecodeBudget) error {
 	// FUTURE: check for schema.TypedNodeBuilder that's going to parse a Link (they can slurp any token kind they want).
 	switch tk.Type {
 	case tok.TMapOpen:
@@ -60,6 +71,10 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 			expectLen = math.MaxInt32
 			allocLen = 0
 		}
+		if err := budget.Enter(tk.Length); err != nil {
+			return err
+		}
+		defer budget.Leave()
 		ma, err := na.BeginMap(allocLen)
 		if err != nil {
 			return err
@@ -85,11 +100,19 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 			if observedLen > expectLen {
 				return fmt.Errorf("unexpected continuation of map elements beyond declared length")
 			}
+			if expectLen == math.MaxInt32 {
+				if err := budget.Entry(observedLen); err != nil {
+					return err
+				}
+			}
+			if err := budget.Alloc(len(tk.Str)); err != nil {
+				return err
+			}
 			mva, err := ma.AssembleEntry(tk.Str)
 			if err != nil { // return in error if the key was rejected
 				return err
 			}
-			err = Unmarshal(mva, tokSrc)
+			err = unmarshalNext(mva, tokSrc, tk, budget)
 			if err != nil { // return in error if some part of the recursion errored
 				return err
 			}
@@ -103,6 +126,10 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 			expectLen = math.MaxInt32
 			allocLen = 0
 		}
+		if err := budget.Enter(tk.Length); err != nil {
+			return err
+		}
+		defer budget.Leave()
 		la, err := na.BeginList(allocLen)
 		if err != nil {
 			return err
@@ -124,7 +151,12 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 				if observedLen > expectLen {
 					return fmt.Errorf("unexpected continuation of array elements beyond declared length")
 				}
-				err := unmarshal(la.AssembleValue(), tokSrc, tk)
+				if expectLen == math.MaxInt32 {
+					if err := budget.Entry(observedLen); err != nil {
+						return err
+					}
+				}
+				err := unmarshal(la.AssembleValue(), tokSrc, tk, budget)
 				if err != nil { // return in error if some part of the recursion errored
 					return err
 				}
@@ -135,8 +167,14 @@ func unmarshal(na ipld.NodeAssembler, tokSrc shared.TokenSource, tk *tok.Token)
 	case tok.TNull:
 		return na.AssignNull()
 	case tok.TString:
+		if err := budget.Alloc(len(tk.Str)); err != nil {
+			return err
+		}
 		return na.AssignString(tk.Str)
 	case tok.TBytes:
+		if err := budget.Alloc(len(tk.Bytes)); err != nil {
+			return err
+		}
 		return na.AssignBytes(tk.Bytes)
 	case tok.TBool:
 		return na.AssignBool(tk.Bool)
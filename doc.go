@@ -31,6 +31,7 @@
 //   - node/basic -- the first Node implementation you should try
 //   - codec/* -- functions for serializing and deserializing Nodes
 //   - linking/* -- various Link + LinkBuilder implementations
+//   - car -- packaging blocks into CAR files, and reading them back
 //   - traversal -- functions for walking Node graphs (including
 //        automatic link loading) and visiting
 //   - must -- helpful functions for streamlining error handling
@@ -0,0 +1,101 @@
+package traversal
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	ipld "github.com/ipld/go-ipld-prime"
+)
+
+// BudgetKind names one of the resource limits which can be set in a Config.
+type BudgetKind string
+
+const (
+	BudgetKind_Nodes BudgetKind = "nodes" // Config.MaxNodes: the number of nodes visited by a walk.
+	BudgetKind_Links BudgetKind = "links" // Config.MaxLinks: the number of links loaded.
+	BudgetKind_Bytes BudgetKind = "bytes" // Config.MaxBytes: the number of bytes read through the LinkLoader.
+)
+
+// ErrBudgetExceeded is returned by a traversal which was halted because it
+// reached one of the limits set in its Config.
+type ErrBudgetExceeded struct {
+	Budget BudgetKind // Which budget ran out.
+	Limit  int64      // The limit that was configured for that budget.
+	Path   ipld.Path  // The Progress.Path at which the traversal stopped.
+}
+
+func (e ErrBudgetExceeded) Error() string {
+	return fmt.Sprintf("traversal budget exceeded at %q: limit of %d %s reached", e.Path, e.Limit, e.Budget)
+}
+
+// budget tracks how much of the limits in a Config have been used.
+// A single budget is shared (by pointer) by all the Progress values of a
+// traversal, including those handed to visitor functions, so that any
+// further traversals started from within a visitor count toward it too.
+// It's updated atomically, since links may be loaded concurrently
+// (see Config.LinkPrefetchConcurrency).
+type budget struct {
+	nodes int64
+	links int64
+	bytes int64
+}
+
+func (prog Progress) spendNode() error {
+	if nodes := atomic.AddInt64(&prog.budget.nodes, 1); prog.Cfg.MaxNodes > 0 && nodes > prog.Cfg.MaxNodes {
+		return ErrBudgetExceeded{BudgetKind_Nodes, prog.Cfg.MaxNodes, prog.Path}
+	}
+	return nil
+}
+
+func (prog Progress) spendLink() error {
+	if links := atomic.AddInt64(&prog.budget.links, 1); prog.Cfg.MaxLinks > 0 && links > prog.Cfg.MaxLinks {
+		return ErrBudgetExceeded{BudgetKind_Links, prog.Cfg.MaxLinks, prog.Path}
+	}
+	return nil
+}
+
+// bytesExceeded returns an ErrBudgetExceeded if more bytes have been read
+// than are allowed, or nil otherwise.
+//
+// Since errors from reading (see budgetedLoader) may be wrapped or replaced by
+// a codec, this is checked again after any failed load, so that the typed
+// error can be returned reliably.
+func (prog Progress) bytesExceeded() error {
+	if prog.Cfg.MaxBytes > 0 && atomic.LoadInt64(&prog.budget.bytes) > prog.Cfg.MaxBytes {
+		return ErrBudgetExceeded{BudgetKind_Bytes, prog.Cfg.MaxBytes, prog.Path}
+	}
+	return nil
+}
+
+// budgetedLoader returns the Config.LinkLoader, wrapped if necessary so that
+// the bytes read through it are counted against the budget.
+func (prog Progress) budgetedLoader() ipld.Loader {
+	if prog.Cfg.MaxBytes <= 0 {
+		return prog.Cfg.LinkLoader
+	}
+	return func(lnk ipld.Link, lnkCtx ipld.LinkContext) (io.Reader, error) {
+		r, err := prog.Cfg.LinkLoader(lnk, lnkCtx)
+		if err != nil {
+			return nil, err
+		}
+		return &budgetedReader{r, prog}, nil
+	}
+}
+
+type budgetedReader struct {
+	r    io.Reader
+	prog Progress
+}
+
+func (br *budgetedReader) Read(p []byte) (int, error) {
+	if err := br.prog.bytesExceeded(); err != nil {
+		return 0, err
+	}
+	n, err := br.r.Read(p)
+	atomic.AddInt64(&br.prog.budget.bytes, int64(n))
+	if err == nil {
+		err = br.prog.bytesExceeded()
+	}
+	return n, err
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strconv"
 
 	ipld "github.com/ipld/go-ipld-prime"
 	"github.com/ipld/go-ipld-prime/schema"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
 )
 
 // init sets all the values in TraveralConfig to reasonable defaults
@@ -44,4 +46,95 @@ func (prog *Progress) init() {
 		prog.Cfg = &Config{}
 	}
 	prog.Cfg.init()
+	if prog.budget == nil {
+		prog.budget = &budget{}
+	}
+	if prog.visited == nil && prog.Cfg.LinkVisitOnce {
+		prog.visited = &visitedLinks{m: make(map[string][]selector.Selector)}
+	}
+	if prog.prefetch == nil && prog.Cfg.LinkPrefetchConcurrency > 1 {
+		prog.prefetch = make(prefetchSlots, prog.Cfg.LinkPrefetchConcurrency)
+	}
+}
+
+// rebuild creates a copy of a map or list node, using a builder from the
+// node's own style, in which the entries named in replacements
+// (keyed by the string form of their path segment) are substituted.
+// All other entries are carried over as-is, and thus shared with the original.
+func rebuild(n ipld.Node, replacements map[string]ipld.Node) (ipld.Node, error) {
+	nb := n.Style().NewBuilder()
+	switch n.ReprKind() {
+	case ipld.ReprKind_Map:
+		ma, err := nb.BeginMap(n.Length())
+		if err != nil {
+			return nil, err
+		}
+		for itr := n.MapIterator(); !itr.Done(); {
+			k, v, err := itr.Next()
+			if err != nil {
+				return nil, err
+			}
+			ks, err := k.AsString()
+			if err != nil {
+				return nil, err
+			}
+			if r, ok := replacements[ks]; ok {
+				v = r
+			}
+			if err := ma.AssembleKey().AssignNode(k); err != nil {
+				return nil, err
+			}
+			if err := ma.AssembleValue().AssignNode(v); err != nil {
+				return nil, err
+			}
+		}
+		if err := ma.Finish(); err != nil {
+			return nil, err
+		}
+	case ipld.ReprKind_List:
+		la, err := nb.BeginList(n.Length())
+		if err != nil {
+			return nil, err
+		}
+		for itr := n.ListIterator(); !itr.Done(); {
+			idx, v, err := itr.Next()
+			if err != nil {
+				return nil, err
+			}
+			if r, ok := replacements[strconv.Itoa(idx)]; ok {
+				v = r
+			}
+			if err := la.AssembleValue().AssignNode(v); err != nil {
+				return nil, err
+			}
+		}
+		if err := la.Finish(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("cannot rebuild node of kind %s: only maps and lists have children", n.ReprKind())
+	}
+	return nb.Build(), nil
+}
+
+// storeLink encodes a replacement for the content of a link into a new block,
+// using the LinkBuilder of the original link and the Config.LinkStorer,
+// and returns a new link node (of the same style as the original link node)
+// which points to the new block.
+func (prog Progress) storeLink(oldLnk ipld.Link, oldLnkNode ipld.Node, parent ipld.Node, n ipld.Node) (ipld.Node, error) {
+	// Assemble the LinkContext in case the Storer wants it.
+	//  (LinkNode is left zero: the link for new data doesn't exist yet.)
+	lnkCtx := ipld.LinkContext{
+		LinkPath:   prog.Path,
+		ParentNode: parent,
+	}
+	lnk, err := oldLnk.LinkBuilder().Build(prog.Cfg.Ctx, lnkCtx, n, prog.Cfg.LinkStorer)
+	if err != nil {
+		return nil, fmt.Errorf("error transforming node at %q: could not store new block replacing link %q: %s", prog.Path, oldLnk, err)
+	}
+	nb := oldLnkNode.Style().NewBuilder()
+	if err := nb.AssignLink(lnk); err != nil {
+		return nil, fmt.Errorf("error transforming node at %q: could not assign new link %q: %s", prog.Path, lnk, err)
+	}
+	return nb.Build(), nil
 }
@@ -35,15 +35,34 @@ type Progress struct {
 		Path ipld.Path
 		Link ipld.Link
 	}
+	Labels []string // Labels of the Matchers which selected the current node, if they have any.  (Only set on visits with VisitReason_SelectionMatch.)
+
+	budget   *budget       // Usage of the limits in Cfg so far.  Shared by every Progress in a traversal.
+	visited  *visitedLinks // Links visited so far, if Cfg.LinkVisitOnce is set.  Shared by every Progress in a traversal.
+	prefetch prefetchSlots // Slots for links being loaded concurrently, if Cfg.LinkPrefetchConcurrency is above one.  Shared by every Progress in a traversal.
 }
 
 type Config struct {
 	Ctx                        context.Context            // Context carried through a traversal.  Optional; use it if you need cancellation.
 	LinkLoader                 ipld.Loader                // Loader used for automatic link traversal.
 	LinkTargetNodeStyleChooser LinkTargetNodeStyleChooser // Chooser for Node implementations to produce during automatic link traversal.
-	LinkStorer                 ipld.Storer                // Storer used if any mutation features (e.g. traversal.Transform) are used.
+	LinkStorer                 ipld.Storer                // Storer used if any mutation features (e.g. traversal.FocusedTransform) change data beneath a link.
+	MaxNodes                   int64                      // Maximum number of nodes a walk may visit before failing with ErrBudgetExceeded.  Optional; zero means no limit.
+	MaxLinks                   int64                      // Maximum number of links a traversal may load before failing with ErrBudgetExceeded.  Optional; zero means no limit.
+	MaxBytes                   int64                      // Maximum number of bytes a traversal may read through the LinkLoader before failing with ErrBudgetExceeded.  Optional; zero means no limit.
+	LinkPrefetchConcurrency    int                        // If greater than one, walks exploring all the children of a node load their links concurrently, ahead of visiting them; up to this many at once in the whole walk.  (Visits still happen in order.  The LinkLoader and LinkTargetNodeStyleChooser must be safe for concurrent use.)
+	LinkVisitOnce              bool                       // If true, walks (other than WalkTransforming) skip any link they've already visited with the same selector, so each block is explored at most once per selector.
+	Order                      TraversalOrder             // Order in which WalkMatching, WalkAdv, and Iterate visit nodes.  Optional; the default is depth-first.
 }
 
+// TraversalOrder is the order in which a walk visits nodes; see Config.Order.
+type TraversalOrder byte
+
+const (
+	TraversalOrder_DepthFirst   TraversalOrder = iota // Visit all of a node's children (and their children, and so on) before its next sibling.  This is the default.
+	TraversalOrder_BreadthFirst                       // Visit every node at one depth before any at the next depth.  (Breadth-first walks don't use Config.LinkPrefetchConcurrency, and can't be resumed from a cursor.)
+)
+
 // LinkTargetNodeStyleChooser is a function that returns a NodeStyle based on
 // the information in a Link and/or its LinkContext.
 //
@@ -57,7 +76,8 @@ type LinkTargetNodeStyleChooser func(ipld.Link, ipld.LinkContext) (ipld.NodeStyl
 
 // SkipMe is a signalling "error" which can be used to tell traverse to skip some data.
 //
-// SkipMe can be returned by the Config.LinkLoader to skip entire blocks without aborting the walk.
+// SkipMe can be returned by the Config.LinkLoader to skip entire blocks without aborting the walk:
+// only the link for which it is returned is skipped, and the walk continues with its siblings.
 // (This can be useful if you know you don't have data on hand,
 // but want to continue the walk in other areas anyway;
 // or, if you're doing a way where you know that it's valid to memoize seen
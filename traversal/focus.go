@@ -2,6 +2,7 @@ package traversal
 
 import (
 	"fmt"
+	"strconv"
 
 	ipld "github.com/ipld/go-ipld-prime"
 )
@@ -88,13 +89,21 @@ func (prog Progress) Focus(n ipld.Node, p ipld.Path, fn VisitFn) error {
 			}
 			nb := ns.NewBuilder()
 			// Load link!
+			progLnk := prog
+			progLnk.Path = prog.Path.Join(p.Truncate(i + 1))
+			if err := progLnk.spendLink(); err != nil {
+				return err
+			}
 			err = lnk.Load(
 				prog.Cfg.Ctx,
 				lnkCtx,
 				nb,
-				prog.Cfg.LinkLoader,
+				progLnk.budgetedLoader(),
 			)
 			if err != nil {
+				if err := progLnk.bytesExceeded(); err != nil {
+					return err
+				}
 				return fmt.Errorf("error traversing node at %q: could not load link %q: %s", p.Truncate(i+1), lnk, err)
 			}
 			prog.LastBlock.Path = p.Truncate(i + 1)
@@ -136,7 +145,119 @@ func (prog Progress) Focus(n ipld.Node, p ipld.Path, fn VisitFn) error {
 // does a large amount of the intermediate bookkeeping that's useful when
 // creating new values which are partial updates to existing values.
 //
-// This feature is not yet implemented.
+// The new intermediate nodes are built using the NodeStyle of the nodes
+// they replace, so the returned tree has the same implementations as the original.
+//
+// Links on the path are loaded as in Focus.
+// If anything beneath a link is replaced, the new content of that block is
+// encoded using the LinkBuilder of the original link, stored using the
+// Config.LinkStorer, and the new link is substituted into the parent;
+// this repeats for every link between the replaced node and the root.
+// (The returned root node itself is not stored; that's up to the caller.)
 func (prog Progress) FocusedTransform(n ipld.Node, p ipld.Path, fn TransformFn) (ipld.Node, error) {
-	panic("TODO") // TODO surprisingly different from Focus -- need to store nodes we traversed, and able do building.
+	prog.init()
+	return prog.focusedTransform(n, p, 0, fn)
+}
+
+// focusedTransform handles one segment of the path for FocusedTransform,
+// recursing to handle the rest, and then rebuilding n if its child changed.
+// The stack of recursive calls is where we keep the nodes we traversed.
+func (prog Progress) focusedTransform(n ipld.Node, p ipld.Path, i int, fn TransformFn) (ipld.Node, error) {
+	segments := p.Segments()
+	if i == len(segments) {
+		prog.Path = prog.Path.Join(p)
+		return fn(prog, n)
+	}
+	seg := segments[i]
+	// Traverse the segment.
+	var next ipld.Node
+	var key string
+	switch n.ReprKind() {
+	case ipld.ReprKind_Invalid:
+		return nil, fmt.Errorf("cannot traverse node at %q: it is undefined", p.Truncate(i))
+	case ipld.ReprKind_Map:
+		var err error
+		next, err = n.LookupString(seg.String())
+		if err != nil {
+			return nil, fmt.Errorf("error traversing segment %q on node at %q: %s", seg, p.Truncate(i), err)
+		}
+		key = seg.String()
+	case ipld.ReprKind_List:
+		intSeg, err := seg.Index()
+		if err != nil {
+			return nil, fmt.Errorf("error traversing segment %q on node at %q: the segment cannot be parsed as a number and the node is a list", seg, p.Truncate(i))
+		}
+		next, err = n.LookupIndex(intSeg)
+		if err != nil {
+			return nil, fmt.Errorf("error traversing segment %q on node at %q: %s", seg, p.Truncate(i), err)
+		}
+		key = strconv.Itoa(intSeg)
+	default:
+		return nil, fmt.Errorf("cannot traverse node at %q: %s", p.Truncate(i), fmt.Errorf("cannot traverse terminals"))
+	}
+	// Dereference any links.
+	//  Keep track of them, so we can store new blocks on the way back up.
+	prev, child := n, next
+	var lnks []ipld.Link
+	var lnkNodes []ipld.Node
+	for child.ReprKind() == ipld.ReprKind_Link {
+		lnk, _ := child.AsLink()
+		// Assemble the LinkContext in case the Loader or NBChooser want it.
+		lnkCtx := ipld.LinkContext{
+			LinkPath:   p.Truncate(i),
+			LinkNode:   child,
+			ParentNode: prev,
+		}
+		// Pick what in-memory format we will build.
+		ns, err := prog.Cfg.LinkTargetNodeStyleChooser(lnk, lnkCtx)
+		if err != nil {
+			return nil, fmt.Errorf("error traversing node at %q: could not load link %q: %s", p.Truncate(i+1), lnk, err)
+		}
+		nb := ns.NewBuilder()
+		// Load link!
+		progLnk := prog
+		progLnk.Path = prog.Path.Join(p.Truncate(i + 1))
+		if err := progLnk.spendLink(); err != nil {
+			return nil, err
+		}
+		err = lnk.Load(
+			prog.Cfg.Ctx,
+			lnkCtx,
+			nb,
+			progLnk.budgetedLoader(),
+		)
+		if err != nil {
+			if err := progLnk.bytesExceeded(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("error traversing node at %q: could not load link %q: %s", p.Truncate(i+1), lnk, err)
+		}
+		prog.LastBlock.Path = p.Truncate(i + 1)
+		prog.LastBlock.Link = lnk
+		lnks = append(lnks, lnk)
+		lnkNodes = append(lnkNodes, child)
+		prev, child = child, nb.Build()
+	}
+	// Recurse, and see if anything changed.
+	child2, err := prog.focusedTransform(child, p, i+1, fn)
+	if err != nil {
+		return nil, err
+	}
+	if child2 == child {
+		return n, nil
+	}
+	// Store new blocks for any links we crossed, innermost first.
+	progLnk := prog
+	progLnk.Path = prog.Path.Join(p.Truncate(i + 1))
+	for j := len(lnks) - 1; j >= 0; j-- {
+		parent := n
+		if j > 0 {
+			parent = lnkNodes[j-1]
+		}
+		child2, err = progLnk.storeLink(lnks[j], lnkNodes[j], parent, child2)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rebuild(n, map[string]ipld.Node{key: child2})
 }
@@ -160,3 +160,125 @@ func TestFocusWithLinkLoading(t *testing.T) {
 		Wish(t, err, ShouldEqual, nil)
 	})
 }
+
+func TestFocusedTransform(t *testing.T) {
+	t.Run("empty path replaces the start node", func(t *testing.T) {
+		n, err := traversal.FocusedTransform(basicnode.NewString("x"), ipld.Path{}, func(prog traversal.Progress, prev ipld.Node) (ipld.Node, error) {
+			Wish(t, prev, ShouldEqual, basicnode.NewString("x"))
+			Wish(t, prog.Path.String(), ShouldEqual, ipld.Path{}.String())
+			return basicnode.NewString("y"), nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, n, ShouldEqual, basicnode.NewString("y"))
+	})
+	t.Run("two step path on map node rebuilds parents", func(t *testing.T) {
+		n, err := traversal.FocusedTransform(middleMapNode, ipld.ParsePath("nested/nonlink"), func(prog traversal.Progress, prev ipld.Node) (ipld.Node, error) {
+			Wish(t, prev, ShouldEqual, basicnode.NewString("zoo"))
+			Wish(t, prog.Path, ShouldEqual, ipld.ParsePath("nested/nonlink"))
+			return basicnode.NewString("new string!"), nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, n, ShouldEqual, fluent.MustBuildMap(basicnode.Style__Map{}, 3, func(na fluent.MapAssembler) {
+			na.AssembleEntry("foo").AssignBool(true)
+			na.AssembleEntry("bar").AssignBool(false)
+			na.AssembleEntry("nested").CreateMap(2, func(na fluent.MapAssembler) {
+				na.AssembleEntry("alink").AssignLink(leafAlphaLnk)
+				na.AssembleEntry("nonlink").AssignString("new string!")
+			})
+		}))
+		// The original is untouched.
+		nested, _ := middleMapNode.LookupString("nested")
+		nonlink, _ := nested.LookupString("nonlink")
+		Wish(t, nonlink, ShouldEqual, basicnode.NewString("zoo"))
+	})
+	t.Run("path through a list index works", func(t *testing.T) {
+		n, err := traversal.FocusedTransform(fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry("list").CreateList(2, func(na fluent.ListAssembler) {
+				na.AssembleValue().AssignString("alpha")
+				na.AssembleValue().AssignString("beta")
+			})
+		}), ipld.ParsePath("list/1"), func(prog traversal.Progress, prev ipld.Node) (ipld.Node, error) {
+			Wish(t, prev, ShouldEqual, basicnode.NewString("beta"))
+			Wish(t, prog.Path, ShouldEqual, ipld.ParsePath("list/1"))
+			return basicnode.NewString("gamma"), nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, n, ShouldEqual, fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry("list").CreateList(2, func(na fluent.ListAssembler) {
+				na.AssembleValue().AssignString("alpha")
+				na.AssembleValue().AssignString("gamma")
+			})
+		}))
+	})
+	t.Run("no-op transform returns the same root", func(t *testing.T) {
+		n, err := traversal.FocusedTransform(middleMapNode, ipld.ParsePath("nested/nonlink"), func(prog traversal.Progress, prev ipld.Node) (ipld.Node, error) {
+			return prev, nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, n == middleMapNode, ShouldEqual, true)
+	})
+	t.Run("missing map key should fail", func(t *testing.T) {
+		_, err := traversal.FocusedTransform(middleMapNode, ipld.ParsePath("nested/nope"), func(prog traversal.Progress, prev ipld.Node) (ipld.Node, error) {
+			t.Errorf("should not be reached; no such path")
+			return prev, nil
+		})
+		Wish(t, err == nil, ShouldEqual, false)
+	})
+	t.Run("transform beneath a link with no configured storer should fail", func(t *testing.T) {
+		_, err := traversal.Progress{
+			Cfg: &traversal.Config{
+				LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+					return bytes.NewBuffer(storage[lnk]), nil
+				},
+				LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+					return basicnode.Style__Any{}, nil
+				},
+			},
+		}.FocusedTransform(rootNode, ipld.ParsePath("linkedMap/nested/nonlink"), func(prog traversal.Progress, prev ipld.Node) (ipld.Node, error) {
+			Wish(t, prog.LastBlock.Path, ShouldEqual, ipld.ParsePath("linkedMap"))
+			return basicnode.NewString("new string!"), nil
+		})
+		Wish(t, err.Error(), ShouldEqual, `error transforming node at "linkedMap": could not store new block replacing link "`+middleMapNodeLnk.String()+`": no link storer configured`)
+	})
+	t.Run("transform beneath a link should store a new block", func(t *testing.T) {
+		var stored []ipld.Link
+		n, err := traversal.Progress{
+			Cfg: &traversal.Config{
+				LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+					return bytes.NewBuffer(storage[lnk]), nil
+				},
+				LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+					return basicnode.Style__Any{}, nil
+				},
+				LinkStorer: func(lnkCtx ipld.LinkContext) (io.Writer, ipld.StoreCommitter, error) {
+					Wish(t, lnkCtx.LinkPath, ShouldEqual, ipld.ParsePath("linkedMap"))
+					buf := bytes.Buffer{}
+					return &buf, func(lnk ipld.Link) error {
+						storage[lnk] = buf.Bytes()
+						stored = append(stored, lnk)
+						return nil
+					}, nil
+				},
+			},
+		}.FocusedTransform(rootNode, ipld.ParsePath("linkedMap/nested/nonlink"), func(prog traversal.Progress, prev ipld.Node) (ipld.Node, error) {
+			return basicnode.NewString("new string!"), nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Require(t, len(stored), ShouldEqual, 1)
+		_, expectLnk := encode(fluent.MustBuildMap(basicnode.Style__Map{}, 3, func(na fluent.MapAssembler) {
+			na.AssembleEntry("foo").AssignBool(true)
+			na.AssembleEntry("bar").AssignBool(false)
+			na.AssembleEntry("nested").CreateMap(2, func(na fluent.MapAssembler) {
+				na.AssembleEntry("alink").AssignLink(leafAlphaLnk)
+				na.AssembleEntry("nonlink").AssignString("new string!")
+			})
+		}))
+		Wish(t, stored[0], ShouldEqual, expectLnk)
+		Wish(t, n, ShouldEqual, fluent.MustBuildMap(basicnode.Style__Map{}, 4, func(na fluent.MapAssembler) {
+			na.AssembleEntry("plain").AssignString("olde string")
+			na.AssembleEntry("linkedString").AssignLink(leafAlphaLnk)
+			na.AssembleEntry("linkedMap").AssignLink(expectLnk)
+			na.AssembleEntry("linkedList").AssignLink(middleListNodeLnk)
+		}))
+	})
+}
@@ -0,0 +1,121 @@
+package traversal
+
+import (
+	"context"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+)
+
+// Iterate starts a walk like Progress.WalkAdv, but rather than calling a
+// function for each visit, returns a WalkIterator which yields the visits
+// one at a time (in the order set by Config.Order).
+//
+// The walk runs in its own goroutine, one visit ahead of the iterator at most.
+// The iterator must either be exhausted or closed; otherwise the goroutine
+// (and any link loading it's doing) will be left waiting forever.
+//
+// The walk's Config.Ctx is replaced with a context derived from it, which is
+// cancelled when the iterator is closed; the Progress yielded by the iterator
+// will have that Config.  If the original Config.Ctx is cancelled, the walk
+// stops, and the iterator yields the context's error.
+func Iterate(prog Progress, n ipld.Node, s selector.Selector) *WalkIterator {
+	prog.init()
+	cfg := *prog.Cfg
+	ctx, cancel := context.WithCancel(cfg.Ctx)
+	cfg.Ctx = ctx
+	prog.Cfg = &cfg
+	it := &WalkIterator{
+		visits: make(chan walkVisit),
+		stop:   make(chan struct{}),
+		cancel: cancel,
+	}
+	go func() {
+		defer close(it.visits)
+		err := prog.walkAdv_ordered(n, s, func(prog Progress, n ipld.Node, tr VisitReason) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			select {
+			case it.visits <- walkVisit{prog, n, tr, nil}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			select {
+			case it.visits <- walkVisit{err: err}:
+			case <-it.stop:
+			}
+		}
+	}()
+	return it
+}
+
+// WalkIterator yields the visits of a walk started by Iterate.
+// Like ipld.MapIterator, it's used by calling Next until Done returns true.
+//
+// A WalkIterator is not safe for concurrent use.
+type WalkIterator struct {
+	visits chan walkVisit
+	stop   chan struct{} // closed by Close, so the walk won't wait to report its error.
+	cancel context.CancelFunc
+	next   *walkVisit // the visit already received from the walk, if any.
+	closed bool
+}
+
+type walkVisit struct {
+	prog Progress
+	n    ipld.Node
+	tr   VisitReason
+	err  error
+}
+
+// Next returns the next visit of the walk.
+//
+// If the walk failed, Next returns the error (after all the visits which
+// happened before it), and the iterator is then done.
+// Calling Next after the iterator is done returns ipld.ErrIteratorOverread.
+func (it *WalkIterator) Next() (Progress, ipld.Node, VisitReason, error) {
+	if it.Done() {
+		return Progress{}, nil, 0, ipld.ErrIteratorOverread{}
+	}
+	v := *it.next
+	it.next = nil
+	return v.prog, v.n, v.tr, v.err
+}
+
+// Done returns true if the walk has no more visits (or error) to yield.
+// It may wait for the walk to reach its next visit.
+func (it *WalkIterator) Done() bool {
+	if it.next != nil {
+		return false
+	}
+	if it.closed {
+		return true
+	}
+	v, ok := <-it.visits
+	if !ok {
+		it.cancel() // release the context's resources.
+		return true
+	}
+	it.next = &v
+	return false
+}
+
+// Close stops the walk, if it's not already finished, and waits for it to
+// stop.  After Close, the iterator is done.  Close always returns nil.
+func (it *WalkIterator) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	it.next = nil
+	close(it.stop)
+	it.cancel()
+	for range it.visits {
+		// Drain, so the walk's goroutine can finish.
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package traversal_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+	"github.com/ipld/go-ipld-prime/traversal"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+	"github.com/ipld/go-ipld-prime/traversal/selector/builder"
+)
+
+func TestIterate(t *testing.T) {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	s, err := ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+		ssb.Matcher(),
+		ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+	)).Selector()
+	Require(t, err, ShouldEqual, nil)
+	newProgress := func(ctx context.Context, loads *int32, failOn ipld.Link) traversal.Progress {
+		return traversal.Progress{
+			Cfg: &traversal.Config{
+				Ctx: ctx,
+				LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+					atomic.AddInt32(loads, 1)
+					if lnk == failOn {
+						return nil, fmt.Errorf("nope")
+					}
+					return bytes.NewBuffer(storage[lnk]), nil
+				},
+				LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+					return basicnode.Style__Any{}, nil
+				},
+			},
+		}
+	}
+	var expected []string
+	var loads int32
+	err = newProgress(nil, &loads, nil).WalkAdv(rootNode, s, func(prog traversal.Progress, n ipld.Node, tr traversal.VisitReason) error {
+		expected = append(expected, prog.Path.String()+":"+string(tr))
+		return nil
+	})
+	Require(t, err, ShouldEqual, nil)
+
+	t.Run("iterating should yield the same visits as walking", func(t *testing.T) {
+		var loads int32
+		it := traversal.Iterate(newProgress(nil, &loads, nil), rootNode, s)
+		var visits []string
+		for !it.Done() {
+			prog, _, tr, err := it.Next()
+			Require(t, err, ShouldEqual, nil)
+			visits = append(visits, prog.Path.String()+":"+string(tr))
+		}
+		Wish(t, visits, ShouldEqual, expected)
+		_, _, _, err := it.Next()
+		Wish(t, err, ShouldEqual, ipld.ErrIteratorOverread{})
+		Wish(t, it.Close(), ShouldEqual, nil)
+	})
+	t.Run("closing early should stop the walk", func(t *testing.T) {
+		var loads int32
+		it := traversal.Iterate(newProgress(nil, &loads, nil), rootNode, s)
+		for i := 0; i < 3; i++ {
+			Require(t, it.Done(), ShouldEqual, false)
+			_, _, _, err := it.Next()
+			Require(t, err, ShouldEqual, nil)
+		}
+		Wish(t, it.Close(), ShouldEqual, nil)
+		Wish(t, it.Done(), ShouldEqual, true)
+		loadsAtClose := atomic.LoadInt32(&loads)
+		Wish(t, loadsAtClose < 8, ShouldEqual, true)
+		Wish(t, atomic.LoadInt32(&loads), ShouldEqual, loadsAtClose)
+	})
+	t.Run("walk errors should be yielded after the visits before them", func(t *testing.T) {
+		var loads int32
+		it := traversal.Iterate(newProgress(nil, &loads, middleMapNodeLnk), rootNode, s)
+		var visits []string
+		var err error
+		for !it.Done() {
+			var prog traversal.Progress
+			var tr traversal.VisitReason
+			prog, _, tr, err = it.Next()
+			if err != nil {
+				break
+			}
+			visits = append(visits, prog.Path.String()+":"+string(tr))
+		}
+		Wish(t, visits, ShouldEqual, expected[:3])
+		Wish(t, err.Error(), ShouldEqual, `error traversing node at "linkedMap": could not load link "baguqefye7xlxqda": nope`)
+		Wish(t, it.Done(), ShouldEqual, true)
+	})
+	t.Run("cancelling the config's context should stop the walk", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var loads int32
+		it := traversal.Iterate(newProgress(ctx, &loads, nil), rootNode, s)
+		_, _, _, err := it.Next()
+		Require(t, err, ShouldEqual, nil)
+		cancel()
+		var lastErr error
+		for !it.Done() {
+			_, _, _, lastErr = it.Next()
+		}
+		Wish(t, lastErr, ShouldEqual, context.Canceled)
+	})
+}
@@ -0,0 +1,109 @@
+package traversal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+)
+
+// ProofBlock is one block of a proof collected by CollectProof:
+// a link, and the raw bytes of the block it links to.
+type ProofBlock struct {
+	Link ipld.Link
+	Data []byte
+}
+
+// CollectProof walks the graph beneath the given root link with a selector,
+// and returns every block the walk loaded, in the order they were first loaded
+// (beginning with the root itself).
+//
+// These are the blocks containing every node visited by the walk -- both those
+// the selector matches, and the "covered" nodes (those visited with
+// VisitReason_SelectionCandidate) which had to be examined in order to find
+// them -- so they're sufficient to repeat the walk, which is what VerifyProof does.
+// A client which trusts only the root link can thus use the proof to check
+// the results of the selection, without trusting whoever supplied the blocks.
+//
+// The blocks are read using Config.LinkLoader, and otherwise the walk is as
+// in WalkAdv (so, for example, Config.LinkVisitOnce makes sense here too).
+func CollectProof(prog Progress, root ipld.Link, s selector.Selector) ([]ProofBlock, error) {
+	prog.init()
+	cfg := *prog.Cfg
+	load := cfg.LinkLoader
+	var (
+		mu     sync.Mutex // loads may be concurrent; see Config.LinkPrefetchConcurrency.
+		blocks []ProofBlock
+		seen   = map[string]bool{}
+	)
+	cfg.LinkLoader = func(lnk ipld.Link, lnkCtx ipld.LinkContext) (io.Reader, error) {
+		r, err := load(lnk, lnkCtx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		if !seen[lnk.String()] {
+			seen[lnk.String()] = true
+			blocks = append(blocks, ProofBlock{lnk, data})
+		}
+		mu.Unlock()
+		return bytes.NewReader(data), nil
+	}
+	prog.Cfg = &cfg
+	n, err := prog.LoadRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := prog.walkAdv_ordered(n, s, func(Progress, ipld.Node, VisitReason) error { return nil }); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// VerifyProof checks a proof collected by CollectProof: it repeats the walk
+// from the root link with the selector, loading blocks only from the proof.
+// The VisitFn (if not nil) is called for every node the selector matches,
+// as in WalkMatching; the results can be trusted if VerifyProof returns nil.
+//
+// Every block is checked against the link it's loaded for when it's loaded
+// (by the Link implementation's Load method; cidlink, for example, checks
+// the block's hash), so a proof with altered blocks is rejected; and a proof
+// which is missing any block the walk needs is rejected as well.
+//
+// Config.LinkLoader is not used.  Other parts of the Config are, and so for
+// proofs from untrusted sources, setting budgets (Config.MaxNodes, etc) is
+// recommended.
+func VerifyProof(prog Progress, root ipld.Link, s selector.Selector, proof []ProofBlock, fn VisitFn) error {
+	prog.init()
+	cfg := *prog.Cfg
+	blocks := make(map[string][]byte, len(proof))
+	for _, blk := range proof {
+		blocks[blk.Link.String()] = blk.Data
+	}
+	cfg.LinkLoader = func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+		data, ok := blocks[lnk.String()]
+		if !ok {
+			return nil, fmt.Errorf("block is missing from proof")
+		}
+		return bytes.NewReader(data), nil
+	}
+	prog.Cfg = &cfg
+	n, err := prog.LoadRoot(root)
+	if err != nil {
+		return err
+	}
+	return prog.walkAdv_ordered(n, s, func(prog Progress, n ipld.Node, tr VisitReason) error {
+		if tr != VisitReason_SelectionMatch || fn == nil {
+			return nil
+		}
+		return fn(prog, n)
+	})
+}
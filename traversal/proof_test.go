@@ -0,0 +1,93 @@
+package traversal_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+	"github.com/ipld/go-ipld-prime/traversal"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+	"github.com/ipld/go-ipld-prime/traversal/selector/builder"
+)
+
+func TestProof(t *testing.T) {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	sPath, err := ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+		efsb.Insert("linkedMap", ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+			efsb.Insert("nested", ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+				efsb.Insert("alink", ssb.Matcher())
+			}))
+		}))
+	}).Selector()
+	Require(t, err, ShouldEqual, nil)
+	sAll, err := ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+		ssb.Matcher(),
+		ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+	)).Selector()
+	Require(t, err, ShouldEqual, nil)
+	prog := func() traversal.Progress {
+		return traversal.Progress{Cfg: &traversal.Config{
+			LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+				return bytes.NewBuffer(storage[lnk]), nil
+			},
+			LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+				return basicnode.Style__Any{}, nil
+			},
+		}}
+	}
+	links := func(proof []traversal.ProofBlock) []ipld.Link {
+		lnks := make([]ipld.Link, len(proof))
+		for i, blk := range proof {
+			lnks[i] = blk.Link
+		}
+		return lnks
+	}
+	t.Run("proof of a path should contain the blocks along it", func(t *testing.T) {
+		proof, err := traversal.CollectProof(prog(), rootNodeLnk, sPath)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, links(proof), ShouldEqual, []ipld.Link{rootNodeLnk, middleMapNodeLnk, leafAlphaLnk})
+		Wish(t, proof[1].Data, ShouldEqual, storage[middleMapNodeLnk])
+
+		var matched []string
+		err = traversal.VerifyProof(prog(), rootNodeLnk, sPath, proof, func(prog traversal.Progress, n ipld.Node) error {
+			matched = append(matched, prog.Path.String())
+			Wish(t, n, ShouldEqual, basicnode.NewString("alpha"))
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, matched, ShouldEqual, []string{"linkedMap/nested/alink"})
+	})
+	t.Run("proof of everything should contain each block once", func(t *testing.T) {
+		proof, err := traversal.CollectProof(prog(), rootNodeLnk, sAll)
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, links(proof), ShouldEqual, []ipld.Link{rootNodeLnk, leafAlphaLnk, middleMapNodeLnk, middleListNodeLnk, leafBetaLnk})
+
+		var visits int
+		err = traversal.VerifyProof(prog(), rootNodeLnk, sAll, proof, func(traversal.Progress, ipld.Node) error {
+			visits++
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, visits, ShouldEqual, 14)
+	})
+	t.Run("verifying should reject tampered blocks", func(t *testing.T) {
+		proof, err := traversal.CollectProof(prog(), rootNodeLnk, sPath)
+		Require(t, err, ShouldEqual, nil)
+		proof[2].Data = []byte(`"alphb"`)
+		err = traversal.VerifyProof(prog(), rootNodeLnk, sPath, proof, nil)
+		Require(t, err != nil, ShouldEqual, true)
+		Wish(t, strings.Contains(err.Error(), `error traversing node at "linkedMap/nested/alink": could not load link`), ShouldEqual, true)
+	})
+	t.Run("verifying should reject proofs with missing blocks", func(t *testing.T) {
+		proof, err := traversal.CollectProof(prog(), rootNodeLnk, sPath)
+		Require(t, err, ShouldEqual, nil)
+		err = traversal.VerifyProof(prog(), rootNodeLnk, sPath, proof[:2], nil)
+		Require(t, err != nil, ShouldEqual, true)
+		Wish(t, strings.Contains(err.Error(), "block is missing from proof"), ShouldEqual, true)
+	})
+}
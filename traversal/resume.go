@@ -0,0 +1,128 @@
+package traversal
+
+import (
+	"fmt"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+)
+
+// Cursor returns a Node recording the position of the current visit in a walk,
+// so that the walk can later be continued from just after this visit using
+// ResumeWalkMatching or ResumeWalkAdv.
+//
+// The cursor is built using the given NodeStyle, which must be able to hold
+// maps, lists, and strings (e.g. basicnode.Style__Any).
+// It contains nothing else, so it can be serialized with any codec (e.g. dag-cbor).
+//
+// The cursor only records the path of the visit.
+// The selectors in effect along that path are not stored: since walks are
+// deterministic, they're derived again when resuming, by exploring from the
+// original selector along the path.  (This also means a cursor stays valid
+// regardless of the implementation details of the selectors involved.)
+//
+// Typically, a VisitFn takes a cursor after it has finished its own work for
+// a node, and stores it somewhere durable.
+func (prog Progress) Cursor(ns ipld.NodeStyle) (ipld.Node, error) {
+	var n ipld.Node
+	err := fluent.Recover(func() {
+		n = fluent.MustBuildMap(ns, 1, func(na fluent.MapAssembler) {
+			segs := prog.Path.Segments()
+			na.AssembleEntry("path").CreateList(len(segs), func(na fluent.ListAssembler) {
+				for _, ps := range segs {
+					na.AssembleValue().AssignString(ps.String())
+				}
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// parseCursor returns the path segments recorded in a cursor Node.
+func parseCursor(cursor ipld.Node) ([]string, error) {
+	pathNode, err := cursor.LookupString("path")
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %s", err)
+	}
+	if pathNode.ReprKind() != ipld.ReprKind_List {
+		return nil, fmt.Errorf("invalid cursor: path must be a list")
+	}
+	segs := make([]string, 0, pathNode.Length())
+	for itr := pathNode.ListIterator(); !itr.Done(); {
+		_, v, err := itr.Next()
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %s", err)
+		}
+		s, err := v.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: path segments must be strings")
+		}
+		segs = append(segs, s)
+	}
+	return segs, nil
+}
+
+// ResumeWalkMatching continues a walk begun by WalkMatching from a cursor
+// taken during that walk (see Progress.Cursor).
+//
+// The node and selector must be the same as those the original walk was
+// started with (and so must the Progress.Path, if the walk was nested in another).
+// The VisitFn will then be called for exactly the nodes the original walk
+// would have visited after the visit at which the cursor was taken, in the
+// same order.
+//
+// Only the blocks along the cursor's path are loaded in order to get back to
+// the position of the cursor; everything walked before the cursor is skipped.
+// This also means that budgets (see Config.MaxNodes, etc) start afresh, and
+// that with Config.LinkVisitOnce, links which were visited only before the
+// cursor may be visited again.
+func (prog Progress) ResumeWalkMatching(n ipld.Node, s selector.Selector, cursor ipld.Node, fn VisitFn) error {
+	return prog.ResumeWalkAdv(n, s, cursor, func(prog Progress, n ipld.Node, tr VisitReason) error {
+		if tr != VisitReason_SelectionMatch {
+			return nil
+		}
+		return fn(prog, n)
+	})
+}
+
+// ResumeWalkAdv is to WalkAdv as ResumeWalkMatching is to WalkMatching.
+// (Cursors from WalkMatching and WalkAdv are interchangeable.)
+func (prog Progress) ResumeWalkAdv(n ipld.Node, s selector.Selector, cursor ipld.Node, fn AdvVisitFn) error {
+	prog.init()
+	if prog.Cfg.Order != TraversalOrder_DepthFirst {
+		return fmt.Errorf("cannot resume walk: only depth-first walks can be resumed from a cursor")
+	}
+	segs, err := parseCursor(cursor)
+	if err != nil {
+		return err
+	}
+	base := prog.Path.Segments()
+	if len(segs) < len(base) {
+		return fmt.Errorf("cannot resume walk at %q: cursor is not beneath it", prog.Path)
+	}
+	for i, ps := range base {
+		if ps.String() != segs[i] {
+			return fmt.Errorf("cannot resume walk at %q: cursor is not beneath it", prog.Path)
+		}
+	}
+	return prog.walkAdv_resume(n, s, segs[len(base):], fn)
+}
+
+// walkAdv_resume is walkAdv for a node which was already visited before the
+// cursor was taken (either the node at the cursor, or one on the way to it);
+// it only walks the children of the node which come after the cursor.
+// The remaining segments of the cursor's path beneath the node are given.
+func (prog Progress) walkAdv_resume(n ipld.Node, s selector.Selector, remaining []string, fn AdvVisitFn) error {
+	if len(remaining) == 0 {
+		return prog.walkAdv_iterate(n, s, nil, fn)
+	}
+	return prog.walkAdv_iterate(n, s, remaining, fn)
+}
+
+func (prog Progress) errCursorNotFound(remaining []string) error {
+	return fmt.Errorf("cannot resume walk: cursor path segment %q not found at %q", remaining[0], prog.Path)
+}
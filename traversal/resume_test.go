@@ -0,0 +1,123 @@
+package traversal_test
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+	"github.com/ipld/go-ipld-prime/traversal"
+	"github.com/ipld/go-ipld-prime/traversal/selector"
+	"github.com/ipld/go-ipld-prime/traversal/selector/builder"
+)
+
+func TestResumeWalk(t *testing.T) {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	newProgress := func(loads *int, prefetch int) traversal.Progress {
+		var mu sync.Mutex // loads may be concurrent, when prefetching.
+		return traversal.Progress{
+			Cfg: &traversal.Config{
+				LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+					mu.Lock()
+					*loads++
+					mu.Unlock()
+					return bytes.NewBuffer(storage[lnk]), nil
+				},
+				LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+					return basicnode.Style__Any{}, nil
+				},
+				LinkPrefetchConcurrency: prefetch,
+			},
+		}
+	}
+	// roundTrip serializes a cursor with dag-cbor and reads it back.
+	roundTrip := func(t *testing.T, cursor ipld.Node) ipld.Node {
+		var buf bytes.Buffer
+		Require(t, dagcbor.Encoder(cursor, &buf), ShouldEqual, nil)
+		nb := basicnode.Style__Any{}.NewBuilder()
+		Require(t, dagcbor.Decoder(nb, &buf), ShouldEqual, nil)
+		return nb.Build()
+	}
+	for _, tc := range []struct {
+		name string
+		ss   builder.SelectorSpec
+	}{
+		{"everything", ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+			ssb.Matcher(),
+			ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+		))},
+		{"selected fields", ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+			efsb.Insert("linkedList", ssb.ExploreRange(1, 4, ssb.Matcher()))
+			efsb.Insert("linkedMap", ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+				efsb.Insert("nested", ssb.ExploreAll(ssb.Matcher()))
+				efsb.Insert("foo", ssb.Matcher())
+			}))
+		})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := tc.ss.Selector()
+			Require(t, err, ShouldEqual, nil)
+			// Walk everything, taking a cursor at every visit.
+			var fullLoads int
+			var visits []string
+			var cursors []ipld.Node
+			err = newProgress(&fullLoads, 0).WalkAdv(rootNode, s, func(prog traversal.Progress, n ipld.Node, tr traversal.VisitReason) error {
+				visits = append(visits, prog.Path.String()+":"+string(tr))
+				cursor, err := prog.Cursor(basicnode.Style__Any{})
+				cursors = append(cursors, cursor)
+				return err
+			})
+			Require(t, err, ShouldEqual, nil)
+			// Resuming from each cursor should produce the rest of the visits.
+			for i, cursor := range cursors {
+				for _, prefetch := range []int{0, 2} {
+					var loads int
+					var resumed []string
+					err := newProgress(&loads, prefetch).ResumeWalkAdv(rootNode, s, roundTrip(t, cursor), func(prog traversal.Progress, n ipld.Node, tr traversal.VisitReason) error {
+						resumed = append(resumed, prog.Path.String()+":"+string(tr))
+						return nil
+					})
+					Wish(t, err, ShouldEqual, nil)
+					Wish(t, resumed, ShouldEqual, append([]string(nil), visits[i+1:]...))
+					Wish(t, loads <= fullLoads, ShouldEqual, true)
+				}
+			}
+		})
+	}
+	t.Run("resuming near the end should not load earlier blocks", func(t *testing.T) {
+		s, err := ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+			ssb.Matcher(),
+			ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+		)).Selector()
+		Require(t, err, ShouldEqual, nil)
+		cursor, err := traversal.Progress{Path: ipld.ParsePath("linkedList/2")}.Cursor(basicnode.Style__Any{})
+		Require(t, err, ShouldEqual, nil)
+		var loads int
+		var resumed []string
+		err = newProgress(&loads, 0).ResumeWalkMatching(rootNode, s, cursor, func(prog traversal.Progress, n ipld.Node) error {
+			resumed = append(resumed, prog.Path.String())
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, resumed, ShouldEqual, []string{"linkedList/3"})
+		Wish(t, loads, ShouldEqual, 3)
+	})
+	t.Run("resuming from a cursor that the selector does not reach should fail", func(t *testing.T) {
+		s, err := ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+			efsb.Insert("plain", ssb.Matcher())
+		}).Selector()
+		Require(t, err, ShouldEqual, nil)
+		cursor, err := traversal.Progress{Path: ipld.ParsePath("linkedList/2")}.Cursor(basicnode.Style__Any{})
+		Require(t, err, ShouldEqual, nil)
+		var loads int
+		err = newProgress(&loads, 0).ResumeWalkMatching(rootNode, s, cursor, func(prog traversal.Progress, n ipld.Node) error {
+			return nil
+		})
+		Wish(t, err.Error(), ShouldEqual, `cannot resume walk: cursor path segment "linkedList" not found at ""`)
+	})
+}
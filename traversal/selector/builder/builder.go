@@ -3,6 +3,7 @@ package builder
 import (
 	ipld "github.com/ipld/go-ipld-prime"
 	"github.com/ipld/go-ipld-prime/fluent"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
 	selector "github.com/ipld/go-ipld-prime/traversal/selector"
 )
 
@@ -25,12 +26,15 @@ type SelectorSpec interface {
 type SelectorSpecBuilder interface {
 	ExploreRecursiveEdge() SelectorSpec
 	ExploreRecursive(limit selector.RecursionLimit, sequence SelectorSpec) SelectorSpec
+	ExploreRecursiveWithStopAt(limit selector.RecursionLimit, sequence SelectorSpec, stopAt selector.Condition) SelectorSpec
 	ExploreUnion(...SelectorSpec) SelectorSpec
 	ExploreAll(next SelectorSpec) SelectorSpec
 	ExploreIndex(index int, next SelectorSpec) SelectorSpec
 	ExploreRange(start int, end int, next SelectorSpec) SelectorSpec
 	ExploreFields(ExploreFieldsSpecBuildingClosure) SelectorSpec
+	ExploreConditional(condition selector.Condition, next SelectorSpec) SelectorSpec
 	Matcher() SelectorSpec
+	MatcherWith(onlyIf *selector.Condition, label string) SelectorSpec
 }
 
 // ExploreFieldsSpecBuildingClosure is a function that provided to SelectorSpecBuilder's
@@ -60,6 +64,46 @@ func (ss selectorSpec) Selector() (selector.Selector, error) {
 	return selector.ParseSelector(ss.n)
 }
 
+// ParseText parses a selector written in the text syntax
+// (see selector.ParseTextNode) and returns the Selector.
+// The selector Node is built with basicnode; use ParseTextSpec to choose
+// another NodeStyle, or to get the Node as well.
+func ParseText(text string) (selector.Selector, error) {
+	ss, err := ParseTextSpec(text, basicnode.Style__Any{})
+	if err != nil {
+		return nil, err
+	}
+	return ss.Selector()
+}
+
+// ParseTextSpec parses a selector written in the text syntax
+// (see selector.ParseTextNode) into a SelectorSpec which will store
+// data in the format determined by the given ipld.NodeStyle.
+func ParseTextSpec(text string, ns ipld.NodeStyle) (SelectorSpec, error) {
+	n, err := selector.ParseTextNode(text, ns)
+	if err != nil {
+		return nil, err
+	}
+	return selectorSpec{n}, nil
+}
+
+// PrintText returns the text syntax for a SelectorSpec
+// (see selector.ParseTextNode).
+func PrintText(ss SelectorSpec) (string, error) {
+	return selector.PrintText(ss.Node())
+}
+
+// Normalize returns a SelectorSpec for the canonical, minimal form of the
+// given SelectorSpec (see selector.Normalize), which will store data in the
+// format determined by the given ipld.NodeStyle.
+func Normalize(ss SelectorSpec, ns ipld.NodeStyle) (SelectorSpec, error) {
+	n, err := selector.Normalize(ss.Node(), ns)
+	if err != nil {
+		return nil, err
+	}
+	return selectorSpec{n}, nil
+}
+
 // NewSelectorSpecBuilder creates a SelectorSpecBuilder which will store
 // data in the format determined by the given ipld.NodeStyle.
 func NewSelectorSpecBuilder(ns ipld.NodeStyle) SelectorSpecBuilder {
@@ -75,9 +119,21 @@ func (ssb *selectorSpecBuilder) ExploreRecursiveEdge() SelectorSpec {
 }
 
 func (ssb *selectorSpecBuilder) ExploreRecursive(limit selector.RecursionLimit, sequence SelectorSpec) SelectorSpec {
+	return ssb.exploreRecursive(limit, sequence, nil)
+}
+
+func (ssb *selectorSpecBuilder) ExploreRecursiveWithStopAt(limit selector.RecursionLimit, sequence SelectorSpec, stopAt selector.Condition) SelectorSpec {
+	return ssb.exploreRecursive(limit, sequence, &stopAt)
+}
+
+func (ssb *selectorSpecBuilder) exploreRecursive(limit selector.RecursionLimit, sequence SelectorSpec, stopAt *selector.Condition) SelectorSpec {
+	size := 2
+	if stopAt != nil {
+		size++
+	}
 	return selectorSpec{
 		fluent.MustBuildMap(ssb.ns, 1, func(na fluent.MapAssembler) {
-			na.AssembleEntry(selector.SelectorKey_ExploreRecursive).CreateMap(2, func(na fluent.MapAssembler) {
+			na.AssembleEntry(selector.SelectorKey_ExploreRecursive).CreateMap(size, func(na fluent.MapAssembler) {
 				na.AssembleEntry(selector.SelectorKey_Limit).CreateMap(1, func(na fluent.MapAssembler) {
 					switch limit.Mode() {
 					case selector.RecursionLimit_Depth:
@@ -89,6 +145,9 @@ func (ssb *selectorSpecBuilder) ExploreRecursive(limit selector.RecursionLimit,
 					}
 				})
 				na.AssembleEntry(selector.SelectorKey_Sequence).AssignNode(sequence.Node())
+				if stopAt != nil {
+					assembleCondition(na.AssembleEntry(selector.SelectorKey_StopAt), *stopAt)
+				}
 			})
 		}),
 	}
@@ -150,6 +209,17 @@ func (ssb *selectorSpecBuilder) ExploreFields(specBuilder ExploreFieldsSpecBuild
 	}
 }
 
+func (ssb *selectorSpecBuilder) ExploreConditional(condition selector.Condition, next SelectorSpec) SelectorSpec {
+	return selectorSpec{
+		fluent.MustBuildMap(ssb.ns, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(selector.SelectorKey_ExploreConditional).CreateMap(2, func(na fluent.MapAssembler) {
+				assembleCondition(na.AssembleEntry(selector.SelectorKey_Condition), condition)
+				na.AssembleEntry(selector.SelectorKey_Next).AssignNode(next.Node())
+			})
+		}),
+	}
+}
+
 func (ssb *selectorSpecBuilder) Matcher() SelectorSpec {
 	return selectorSpec{
 		fluent.MustBuildMap(ssb.ns, 1, func(na fluent.MapAssembler) {
@@ -158,6 +228,68 @@ func (ssb *selectorSpecBuilder) Matcher() SelectorSpec {
 	}
 }
 
+// MatcherWith builds a Matcher which only matches nodes for which the given
+// condition holds (or all nodes, if the condition is nil), and which reports
+// the given label for its matches (or none, if the label is empty).
+func (ssb *selectorSpecBuilder) MatcherWith(onlyIf *selector.Condition, label string) SelectorSpec {
+	size := 0
+	if onlyIf != nil {
+		size++
+	}
+	if label != "" {
+		size++
+	}
+	return selectorSpec{
+		fluent.MustBuildMap(ssb.ns, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(selector.SelectorKey_Matcher).CreateMap(size, func(na fluent.MapAssembler) {
+				if onlyIf != nil {
+					assembleCondition(na.AssembleEntry(selector.SelectorKey_Condition), *onlyIf)
+				}
+				if label != "" {
+					na.AssembleEntry(selector.SelectorKey_Label).AssignString(label)
+				}
+			})
+		}),
+	}
+}
+
+func assembleCondition(na fluent.NodeAssembler, c selector.Condition) {
+	na.CreateMap(1, func(na fluent.MapAssembler) {
+		switch c.Mode() {
+		case selector.ConditionMode_HasField:
+			subs := c.Conditions()
+			na.AssembleEntry(selector.SelectorKey_ConditionHasField).CreateMap(1+len(subs), func(na fluent.MapAssembler) {
+				na.AssembleEntry(selector.SelectorKey_FieldName).AssignString(c.FieldName())
+				for _, sub := range subs {
+					assembleCondition(na.AssembleEntry(selector.SelectorKey_Condition), sub)
+				}
+			})
+		case selector.ConditionMode_HasValue:
+			na.AssembleEntry(selector.SelectorKey_ConditionHasValue).AssignNode(c.Value())
+		case selector.ConditionMode_HasKind:
+			na.AssembleEntry(selector.SelectorKey_ConditionHasKind).AssignString(c.Kind().String())
+		case selector.ConditionMode_IsLink:
+			na.AssembleEntry(selector.SelectorKey_ConditionIsLink).CreateMap(0, func(na fluent.MapAssembler) {})
+		case selector.ConditionMode_GreaterThan:
+			na.AssembleEntry(selector.SelectorKey_ConditionGreaterThan).AssignNode(c.Value())
+		case selector.ConditionMode_LessThan:
+			na.AssembleEntry(selector.SelectorKey_ConditionLessThan).AssignNode(c.Value())
+		case selector.ConditionMode_And, selector.ConditionMode_Or:
+			k := selector.SelectorKey_ConditionAnd
+			if c.Mode() == selector.ConditionMode_Or {
+				k = selector.SelectorKey_ConditionOr
+			}
+			na.AssembleEntry(k).CreateList(len(c.Conditions()), func(na fluent.ListAssembler) {
+				for _, sub := range c.Conditions() {
+					assembleCondition(na.AssembleValue(), sub)
+				}
+			})
+		default:
+			panic("Unsupported condition type")
+		}
+	})
+}
+
 type exploreFieldsSpecBuilder struct {
 	na fluent.MapAssembler
 }
@@ -1,8 +1,10 @@
 package builder
 
 import (
+	"fmt"
 	"testing"
 
+	ipld "github.com/ipld/go-ipld-prime"
 	"github.com/ipld/go-ipld-prime/fluent"
 	basicnode "github.com/ipld/go-ipld-prime/node/basic"
 	"github.com/ipld/go-ipld-prime/traversal/selector"
@@ -128,4 +130,98 @@ func TestBuildingSelectors(t *testing.T) {
 		})
 		Wish(t, sn, ShouldEqual, esn)
 	})
+	t.Run("MatcherWith builds matcher nodes with conditions and labels", func(t *testing.T) {
+		cond := selector.ConditionFieldMatches("type", selector.ConditionHasValue(basicnode.NewString("dir")))
+		sn := ssb.MatcherWith(&cond, "dirs").Node()
+		esn := fluent.MustBuildMap(ns, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(selector.SelectorKey_Matcher).CreateMap(2, func(na fluent.MapAssembler) {
+				na.AssembleEntry(selector.SelectorKey_Condition).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(selector.SelectorKey_ConditionHasField).CreateMap(2, func(na fluent.MapAssembler) {
+						na.AssembleEntry(selector.SelectorKey_FieldName).AssignString("type")
+						na.AssembleEntry(selector.SelectorKey_Condition).CreateMap(1, func(na fluent.MapAssembler) {
+							na.AssembleEntry(selector.SelectorKey_ConditionHasValue).AssignString("dir")
+						})
+					})
+				})
+				na.AssembleEntry(selector.SelectorKey_Label).AssignString("dirs")
+			})
+		})
+		Wish(t, sn, ShouldEqual, esn)
+		s, err := ssb.MatcherWith(&cond, "dirs").Selector()
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, s, ShouldEqual, selector.NewMatcher(&cond, "dirs"))
+		sn = ssb.MatcherWith(nil, "").Node()
+		Wish(t, sn, ShouldEqual, ssb.Matcher().Node())
+	})
+	t.Run("ExploreConditional builds ExploreConditional nodes", func(t *testing.T) {
+		sn := ssb.ExploreConditional(selector.ConditionIsLink(), ssb.Matcher()).Node()
+		esn := fluent.MustBuildMap(ns, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(selector.SelectorKey_ExploreConditional).CreateMap(2, func(na fluent.MapAssembler) {
+				na.AssembleEntry(selector.SelectorKey_Condition).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(selector.SelectorKey_ConditionIsLink).CreateMap(0, func(na fluent.MapAssembler) {})
+				})
+				na.AssembleEntry(selector.SelectorKey_Next).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(selector.SelectorKey_Matcher).CreateMap(0, func(na fluent.MapAssembler) {})
+				})
+			})
+		})
+		Wish(t, sn, ShouldEqual, esn)
+	})
+	t.Run("ExploreRecursiveWithStopAt builds ExploreRecursive nodes with a stopAt condition", func(t *testing.T) {
+		sn := ssb.ExploreRecursiveWithStopAt(selector.RecursionLimitNone(), ssb.ExploreAll(ssb.ExploreRecursiveEdge()), selector.ConditionHasKind(ipld.ReprKind_String)).Node()
+		esn := fluent.MustBuildMap(ns, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(selector.SelectorKey_ExploreRecursive).CreateMap(3, func(na fluent.MapAssembler) {
+				na.AssembleEntry(selector.SelectorKey_Limit).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(selector.SelectorKey_LimitNone).CreateMap(0, func(na fluent.MapAssembler) {})
+				})
+				na.AssembleEntry(selector.SelectorKey_Sequence).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(selector.SelectorKey_ExploreAll).CreateMap(1, func(na fluent.MapAssembler) {
+						na.AssembleEntry(selector.SelectorKey_Next).CreateMap(1, func(na fluent.MapAssembler) {
+							na.AssembleEntry(selector.SelectorKey_ExploreRecursiveEdge).CreateMap(0, func(na fluent.MapAssembler) {})
+						})
+					})
+				})
+				na.AssembleEntry(selector.SelectorKey_StopAt).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(selector.SelectorKey_ConditionHasKind).AssignString("String")
+				})
+			})
+		})
+		Wish(t, sn, ShouldEqual, esn)
+		s, err := ssb.ExploreRecursiveWithStopAt(selector.RecursionLimitNone(), ssb.ExploreAll(ssb.ExploreRecursiveEdge()), selector.ConditionHasKind(ipld.ReprKind_String)).Selector()
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, *s.(selector.ExploreRecursive).StopAt(), ShouldEqual, selector.ConditionHasKind(ipld.ReprKind_String))
+	})
+	t.Run("text syntax round trips with built nodes", func(t *testing.T) {
+		ss := ssb.ExploreRecursive(selector.RecursionLimitDepth(5), ssb.ExploreUnion(
+			ssb.Matcher(),
+			ssb.ExploreFields(func(efsb ExploreFieldsSpecBuilder) {
+				efsb.Insert("parents", ssb.ExploreAll(ssb.ExploreRecursiveEdge()))
+			}),
+		))
+		text, err := PrintText(ss)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, text, ShouldEqual, `recursive(depth=5, union(match, fields(parents=all(edge))))`)
+		parsed, err := ParseTextSpec(text, ns)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, parsed.Node(), ShouldEqual, ss.Node())
+	})
+	t.Run("text syntax parses straight to a Selector", func(t *testing.T) {
+		s, err := ParseText(`recursive(depth=5, all(edge))`)
+		Wish(t, err, ShouldEqual, nil)
+		expected, err := ssb.ExploreRecursive(selector.RecursionLimitDepth(5), ssb.ExploreAll(ssb.ExploreRecursiveEdge())).Selector()
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, s, ShouldEqual, expected)
+		_, err = ParseText(`all(nope)`)
+		Wish(t, err, ShouldEqual, fmt.Errorf(`selector text parse rejected at offset 4: "nope" is not a known selector`))
+	})
+	t.Run("normalizing merges redundant union members", func(t *testing.T) {
+		ss := ssb.ExploreUnion(
+			ssb.ExploreRange(1, 2, ssb.Matcher()),
+			ssb.ExploreUnion(ssb.ExploreAll(ssb.Matcher()), ssb.Matcher()),
+			ssb.Matcher(),
+		)
+		normalized, err := Normalize(ss, ns)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, normalized.Node(), ShouldEqual, ssb.ExploreUnion(ssb.Matcher(), ssb.ExploreAll(ssb.Matcher())).Node())
+	})
 }
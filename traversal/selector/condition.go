@@ -0,0 +1,380 @@
+package selector
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	ipld "github.com/ipld/go-ipld-prime"
+)
+
+// Condition is a predicate which can be evaluated against a single Node.
+// Conditions are used by Matcher (to decide whether a node is part of the
+// result set) and can be composed using ConditionAnd and ConditionOr.
+//
+// Condition is a union type: its Mode says which kind of predicate it is,
+// and the other accessors return the data specific to that mode.
+type Condition struct {
+	mode       ConditionMode
+	fieldName  string        // for ConditionMode_HasField
+	value      ipld.Node     // for ConditionMode_HasValue, ConditionMode_GreaterThan, ConditionMode_LessThan
+	kind       ipld.ReprKind // for ConditionMode_HasKind
+	conditions []Condition   // for ConditionMode_And and ConditionMode_Or; and for ConditionMode_HasField, optionally one condition for the field's value
+}
+
+// ConditionMode is an enum that represents the type of a Condition.
+type ConditionMode uint8
+
+const (
+	// ConditionMode_HasField holds if the node is a map with the named field,
+	// and (if a condition on the field's value is given) that condition holds on the value.
+	ConditionMode_HasField ConditionMode = iota + 1
+	// ConditionMode_HasValue holds if the node is a scalar equal to the given value.
+	ConditionMode_HasValue
+	// ConditionMode_HasKind holds if the node is of the given kind.
+	ConditionMode_HasKind
+	// ConditionMode_IsLink holds if the node is a link.
+	ConditionMode_IsLink
+	// ConditionMode_GreaterThan holds if the node is an int, float, or string
+	// which is greater than the given value.
+	ConditionMode_GreaterThan
+	// ConditionMode_LessThan holds if the node is an int, float, or string
+	// which is less than the given value.
+	ConditionMode_LessThan
+	// ConditionMode_And holds if all of its member conditions hold.
+	ConditionMode_And
+	// ConditionMode_Or holds if any of its member conditions hold.
+	ConditionMode_Or
+)
+
+// ConditionHasField returns a condition which holds on maps which have the named field.
+func ConditionHasField(name string) Condition {
+	return Condition{mode: ConditionMode_HasField, fieldName: name}
+}
+
+// ConditionFieldMatches returns a condition which holds on maps which have
+// the named field, and where the given condition holds on that field's value.
+func ConditionFieldMatches(name string, c Condition) Condition {
+	return Condition{mode: ConditionMode_HasField, fieldName: name, conditions: []Condition{c}}
+}
+
+// ConditionHasValue returns a condition which holds on scalar nodes equal to the given value.
+func ConditionHasValue(v ipld.Node) Condition {
+	return Condition{mode: ConditionMode_HasValue, value: v}
+}
+
+// ConditionHasKind returns a condition which holds on nodes of the given kind.
+func ConditionHasKind(k ipld.ReprKind) Condition {
+	return Condition{mode: ConditionMode_HasKind, kind: k}
+}
+
+// ConditionIsLink returns a condition which holds on link nodes.
+func ConditionIsLink() Condition {
+	return Condition{mode: ConditionMode_IsLink}
+}
+
+// ConditionGreaterThan returns a condition which holds on nodes greater than the given value.
+func ConditionGreaterThan(v ipld.Node) Condition {
+	return Condition{mode: ConditionMode_GreaterThan, value: v}
+}
+
+// ConditionLessThan returns a condition which holds on nodes less than the given value.
+func ConditionLessThan(v ipld.Node) Condition {
+	return Condition{mode: ConditionMode_LessThan, value: v}
+}
+
+// ConditionAnd returns a condition which holds if all the given conditions hold.
+func ConditionAnd(cs ...Condition) Condition {
+	return Condition{mode: ConditionMode_And, conditions: cs}
+}
+
+// ConditionOr returns a condition which holds if any of the given conditions hold.
+func ConditionOr(cs ...Condition) Condition {
+	return Condition{mode: ConditionMode_Or, conditions: cs}
+}
+
+// Mode returns the type of this condition.
+func (c Condition) Mode() ConditionMode {
+	return c.mode
+}
+
+// FieldName returns the field name for a HasField condition, or "" otherwise.
+func (c Condition) FieldName() string {
+	if c.mode != ConditionMode_HasField {
+		return ""
+	}
+	return c.fieldName
+}
+
+// Value returns the value for HasValue, GreaterThan, and LessThan conditions, or nil otherwise.
+func (c Condition) Value() ipld.Node {
+	switch c.mode {
+	case ConditionMode_HasValue, ConditionMode_GreaterThan, ConditionMode_LessThan:
+		return c.value
+	default:
+		return nil
+	}
+}
+
+// Kind returns the kind for a HasKind condition, or ReprKind_Invalid otherwise.
+func (c Condition) Kind() ipld.ReprKind {
+	if c.mode != ConditionMode_HasKind {
+		return ipld.ReprKind_Invalid
+	}
+	return c.kind
+}
+
+// Conditions returns the member conditions of And and Or conditions,
+// or the condition on the field's value (if any) for a HasField condition.
+func (c Condition) Conditions() []Condition {
+	switch c.mode {
+	case ConditionMode_HasField, ConditionMode_And, ConditionMode_Or:
+		return c.conditions
+	default:
+		return nil
+	}
+}
+
+// Match evaluates the condition against the given node.
+// A Condition with no valid mode (such as the zero value) matches nothing.
+func (c Condition) Match(n ipld.Node) bool {
+	switch c.mode {
+	case ConditionMode_HasField:
+		if n.ReprKind() != ipld.ReprKind_Map {
+			return false
+		}
+		v, err := n.LookupString(c.fieldName)
+		if err != nil || v.IsUndefined() {
+			return false
+		}
+		for _, sub := range c.conditions {
+			if !sub.Match(v) {
+				return false
+			}
+		}
+		return true
+	case ConditionMode_HasValue:
+		cmp, ok := compareScalars(n, c.value)
+		return ok && cmp == 0
+	case ConditionMode_HasKind:
+		return n.ReprKind() == c.kind
+	case ConditionMode_IsLink:
+		return n.ReprKind() == ipld.ReprKind_Link
+	case ConditionMode_GreaterThan:
+		cmp, ok := compareScalars(n, c.value)
+		return ok && cmp > 0
+	case ConditionMode_LessThan:
+		cmp, ok := compareScalars(n, c.value)
+		return ok && cmp < 0
+	case ConditionMode_And:
+		for _, sub := range c.conditions {
+			if !sub.Match(n) {
+				return false
+			}
+		}
+		return true
+	case ConditionMode_Or:
+		for _, sub := range c.conditions {
+			if sub.Match(n) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// compareScalars returns -1, 0, or 1 depending on whether a is less than,
+// equal to, or greater than b; or false if the two can't be compared.
+// Ints and floats can be compared with each other; strings, bytes, and bools
+// only with their own kind; links (only for equality) by their string form;
+// null only equals null.  Maps and lists can't be compared.
+func compareScalars(a, b ipld.Node) (int, bool) {
+	switch a.ReprKind() {
+	case ipld.ReprKind_Int, ipld.ReprKind_Float:
+		af, ok := asNumber(a)
+		if !ok {
+			return 0, false
+		}
+		bf, ok := asNumber(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case ipld.ReprKind_String:
+		if b.ReprKind() != ipld.ReprKind_String {
+			return 0, false
+		}
+		as, _ := a.AsString()
+		bs, _ := b.AsString()
+		return strings.Compare(as, bs), true
+	case ipld.ReprKind_Bytes:
+		if b.ReprKind() != ipld.ReprKind_Bytes {
+			return 0, false
+		}
+		ab, _ := a.AsBytes()
+		bb, _ := b.AsBytes()
+		return bytes.Compare(ab, bb), true
+	case ipld.ReprKind_Bool:
+		if b.ReprKind() != ipld.ReprKind_Bool {
+			return 0, false
+		}
+		ab, _ := a.AsBool()
+		bb, _ := b.AsBool()
+		switch {
+		case ab == bb:
+			return 0, true
+		case bb:
+			return -1, true
+		default:
+			return 1, true
+		}
+	case ipld.ReprKind_Null:
+		if b.ReprKind() != ipld.ReprKind_Null {
+			return 0, false
+		}
+		return 0, true
+	case ipld.ReprKind_Link:
+		if b.ReprKind() != ipld.ReprKind_Link {
+			return 0, false
+		}
+		al, _ := a.AsLink()
+		bl, _ := b.AsLink()
+		return strings.Compare(al.String(), bl.String()), true
+	default:
+		return 0, false
+	}
+}
+
+func asNumber(n ipld.Node) (float64, bool) {
+	switch n.ReprKind() {
+	case ipld.ReprKind_Int:
+		i, _ := n.AsInt()
+		return float64(i), true
+	case ipld.ReprKind_Float:
+		f, _ := n.AsFloat()
+		return f, true
+	default:
+		return 0, false
+	}
+}
+
+// ParseCondition assembles a Condition from a condition node.
+func (pc ParseContext) ParseCondition(n ipld.Node) (Condition, error) {
+	if n.ReprKind() != ipld.ReprKind_Map {
+		return Condition{}, fmt.Errorf("selector spec parse rejected: condition is a keyed union and thus must be a map")
+	}
+	if n.Length() != 1 {
+		return Condition{}, fmt.Errorf("selector spec parse rejected: condition is a keyed union and thus must be a single-entry map")
+	}
+	kn, v, _ := n.MapIterator().Next()
+	kstr, _ := kn.AsString()
+	switch kstr {
+	case SelectorKey_ConditionHasField:
+		if v.ReprKind() != ipld.ReprKind_Map {
+			return Condition{}, fmt.Errorf("selector spec parse rejected: hasField condition body must be a map")
+		}
+		nameNode, err := v.LookupString(SelectorKey_FieldName)
+		if err != nil {
+			return Condition{}, fmt.Errorf("selector spec parse rejected: name field must be present in hasField condition")
+		}
+		name, err := nameNode.AsString()
+		if err != nil {
+			return Condition{}, fmt.Errorf("selector spec parse rejected: name field must be a string in hasField condition")
+		}
+		subNode, err := v.LookupString(SelectorKey_Condition)
+		if err != nil {
+			return ConditionHasField(name), nil
+		}
+		sub, err := pc.ParseCondition(subNode)
+		if err != nil {
+			return Condition{}, err
+		}
+		return ConditionFieldMatches(name, sub), nil
+	case SelectorKey_ConditionHasValue:
+		switch v.ReprKind() {
+		case ipld.ReprKind_Map, ipld.ReprKind_List:
+			return Condition{}, fmt.Errorf("selector spec parse rejected: value in %q condition must be a scalar", kstr)
+		}
+		return ConditionHasValue(v), nil
+	case SelectorKey_ConditionGreaterThan, SelectorKey_ConditionLessThan:
+		switch v.ReprKind() {
+		case ipld.ReprKind_Int, ipld.ReprKind_Float, ipld.ReprKind_String:
+		default:
+			return Condition{}, fmt.Errorf("selector spec parse rejected: value in %q condition must be a number or string", kstr)
+		}
+		if kstr == SelectorKey_ConditionGreaterThan {
+			return ConditionGreaterThan(v), nil
+		}
+		return ConditionLessThan(v), nil
+	case SelectorKey_ConditionHasKind:
+		kstr, err := v.AsString()
+		if err != nil {
+			return Condition{}, fmt.Errorf("selector spec parse rejected: kind in %q condition must be a string", SelectorKey_ConditionHasKind)
+		}
+		k, ok := parseReprKind(kstr)
+		if !ok {
+			return Condition{}, fmt.Errorf("selector spec parse rejected: %q is not a known kind", kstr)
+		}
+		return ConditionHasKind(k), nil
+	case SelectorKey_ConditionIsLink:
+		if v.ReprKind() != ipld.ReprKind_Map {
+			return Condition{}, fmt.Errorf("selector spec parse rejected: isLink condition body must be a map")
+		}
+		return ConditionIsLink(), nil
+	case SelectorKey_ConditionAnd, SelectorKey_ConditionOr:
+		if v.ReprKind() != ipld.ReprKind_List {
+			return Condition{}, fmt.Errorf("selector spec parse rejected: %q condition body must be a list", kstr)
+		}
+		cs := make([]Condition, 0, v.Length())
+		for itr := v.ListIterator(); !itr.Done(); {
+			_, cn, err := itr.Next()
+			if err != nil {
+				return Condition{}, fmt.Errorf("error during selector spec parse: %s", err)
+			}
+			c, err := pc.ParseCondition(cn)
+			if err != nil {
+				return Condition{}, err
+			}
+			cs = append(cs, c)
+		}
+		if kstr == SelectorKey_ConditionAnd {
+			return ConditionAnd(cs...), nil
+		}
+		return ConditionOr(cs...), nil
+	default:
+		return Condition{}, fmt.Errorf("selector spec parse rejected: %q is not a known member of the condition union", kstr)
+	}
+}
+
+// reprKinds lists the kinds which may be named in a HasKind condition.
+var reprKinds = []ipld.ReprKind{
+	ipld.ReprKind_Map,
+	ipld.ReprKind_List,
+	ipld.ReprKind_Null,
+	ipld.ReprKind_Bool,
+	ipld.ReprKind_Int,
+	ipld.ReprKind_Float,
+	ipld.ReprKind_String,
+	ipld.ReprKind_Bytes,
+	ipld.ReprKind_Link,
+}
+
+// parseReprKind maps a kind name (as returned by ReprKind.String,
+// compared case-insensitively) back to the ReprKind.
+func parseReprKind(s string) (ipld.ReprKind, bool) {
+	for _, k := range reprKinds {
+		if strings.EqualFold(k.String(), s) {
+			return k, true
+		}
+	}
+	return ipld.ReprKind_Invalid, false
+}
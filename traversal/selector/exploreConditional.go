@@ -0,0 +1,78 @@
+package selector
+
+import (
+	"fmt"
+
+	ipld "github.com/ipld/go-ipld-prime"
+)
+
+// ExploreConditional applies a next selector to the current node only if
+// a Condition holds on that node; if the condition does not hold, the
+// node is neither matched nor explored any further by this selector.
+//
+// This can be used to prune parts of a tree during traversal, for example
+// by descending only into maps which have a certain field with a certain value.
+//
+// Note that the condition is evaluated on the node the selector is applied to,
+// not on the children reached by exploring it.
+type ExploreConditional struct {
+	condition Condition // condition which must hold for next to be applied
+	next      Selector  // selector to apply to the node if the condition holds
+}
+
+// Interests for ExploreConditional are the interests of the next selector
+// (Explore will still return nil for all of them if the condition does not hold)
+func (s ExploreConditional) Interests() []ipld.PathSegment {
+	return s.next.Interests()
+}
+
+// Explore returns the next selector's selector for the given path if the
+// condition holds on the node, or nil if not
+func (s ExploreConditional) Explore(n ipld.Node, p ipld.PathSegment) Selector {
+	if !s.condition.Match(n) {
+		return nil
+	}
+	return s.next.Explore(n, p)
+}
+
+// Decide returns what the next selector decides if the condition holds on the node,
+// or false if not
+func (s ExploreConditional) Decide(n ipld.Node) bool {
+	if !s.condition.Match(n) {
+		return false
+	}
+	return s.next.Decide(n)
+}
+
+// Labels returns the labels of the next selector if the condition holds on the node
+func (s ExploreConditional) Labels(n ipld.Node) []string {
+	if !s.condition.Match(n) {
+		return nil
+	}
+	return Labels(s.next, n)
+}
+
+// ParseExploreConditional assembles a Selector
+// from an ExploreConditional selector node
+func (pc ParseContext) ParseExploreConditional(n ipld.Node) (Selector, error) {
+	if n.ReprKind() != ipld.ReprKind_Map {
+		return nil, fmt.Errorf("selector spec parse rejected: selector body must be a map")
+	}
+	conditionNode, err := n.LookupString(SelectorKey_Condition)
+	if err != nil {
+		return nil, fmt.Errorf("selector spec parse rejected: condition field must be present in ExploreConditional selector")
+	}
+	condition, err := pc.ParseCondition(conditionNode)
+	if err != nil {
+		return nil, err
+	}
+	next, err := n.LookupString(SelectorKey_Next)
+	if err != nil {
+		return nil, fmt.Errorf("selector spec parse rejected: next field must be present in ExploreConditional selector")
+	}
+	selector, err := pc.ParseSelector(next)
+	if err != nil {
+		return nil, err
+	}
+	return ExploreConditional{condition, selector}, nil
+}
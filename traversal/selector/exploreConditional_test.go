@@ -0,0 +1,70 @@
+package selector
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+func TestParseExploreConditional(t *testing.T) {
+	t.Run("parsing non map node should error", func(t *testing.T) {
+		sn := basicnode.NewInt(0)
+		_, err := ParseContext{}.ParseExploreConditional(sn)
+		Wish(t, err, ShouldEqual, fmt.Errorf("selector spec parse rejected: selector body must be a map"))
+	})
+	t.Run("parsing map node without condition field should error", func(t *testing.T) {
+		sn := fluent.MustBuildMap(basicnode.Style__Map{}, 0, func(na fluent.MapAssembler) {})
+		_, err := ParseContext{}.ParseExploreConditional(sn)
+		Wish(t, err, ShouldEqual, fmt.Errorf("selector spec parse rejected: condition field must be present in ExploreConditional selector"))
+	})
+	t.Run("parsing map node without next field should error", func(t *testing.T) {
+		sn := fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(SelectorKey_Condition).CreateMap(1, func(na fluent.MapAssembler) {
+				na.AssembleEntry(SelectorKey_ConditionIsLink).CreateMap(0, func(na fluent.MapAssembler) {})
+			})
+		})
+		_, err := ParseContext{}.ParseExploreConditional(sn)
+		Wish(t, err, ShouldEqual, fmt.Errorf("selector spec parse rejected: next field must be present in ExploreConditional selector"))
+	})
+	t.Run("parsing map node with condition and next fields should parse", func(t *testing.T) {
+		sn := fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+			na.AssembleEntry(SelectorKey_Condition).CreateMap(1, func(na fluent.MapAssembler) {
+				na.AssembleEntry(SelectorKey_ConditionHasField).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(SelectorKey_FieldName).AssignString("type")
+				})
+			})
+			na.AssembleEntry(SelectorKey_Next).CreateMap(1, func(na fluent.MapAssembler) {
+				na.AssembleEntry(SelectorKey_Matcher).CreateMap(0, func(na fluent.MapAssembler) {})
+			})
+		})
+		s, err := ParseContext{}.ParseExploreConditional(sn)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, s, ShouldEqual, ExploreConditional{ConditionHasField("type"), Matcher{}})
+	})
+}
+
+func TestExploreConditionalExplore(t *testing.T) {
+	isDir := ConditionFieldMatches("type", ConditionHasValue(basicnode.NewString("dir")))
+	s := ExploreConditional{isDir, ExploreUnion{[]Selector{Matcher{}, ExploreAll{Matcher{}}}}}
+	dir := fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+		na.AssembleEntry("type").AssignString("dir")
+		na.AssembleEntry("entries").CreateList(0, func(na fluent.ListAssembler) {})
+	})
+	file := fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+		na.AssembleEntry("type").AssignString("file")
+		na.AssembleEntry("data").AssignBytes([]byte{1})
+	})
+	t.Run("node where condition holds is matched and explored", func(t *testing.T) {
+		Wish(t, s.Decide(dir), ShouldEqual, true)
+		Wish(t, s.Explore(dir, ipld.PathSegmentOfString("entries")), ShouldEqual, Matcher{})
+	})
+	t.Run("node where condition does not hold is pruned", func(t *testing.T) {
+		Wish(t, s.Decide(file), ShouldEqual, false)
+		Wish(t, s.Explore(file, ipld.PathSegmentOfString("data")), ShouldEqual, nil)
+	})
+}
@@ -31,10 +31,19 @@ import (
 // Be careful when using ExploreRecursive with a large maxDepth parameter;
 // it can easily cause very large traversals (especially if used in combination
 // with selectors like ExploreAll inside the sequence).
+//
+// ExploreRecursive can also have a stopAt Condition, which halts the recursion
+// at any node where it holds.  The condition is checked on each node before it
+// is explored -- so a condition about a link (e.g. that it's one which was
+// already seen) prevents the link from being loaded at all -- and again on the
+// node itself, so a condition about the content of a node (e.g. the value of
+// one of its fields) prevents anything beneath that node from being explored.
+// Nodes where the condition holds are not matched.
 type ExploreRecursive struct {
 	sequence Selector       // selector for element we're interested in
 	current  Selector       // selector to apply to the current node
 	limit    RecursionLimit // the limit for this recursive selector
+	stopAt   *Condition     // if set, condition which halts the recursion when it holds on a node
 }
 
 // RecursionLimit_Mode is an enum that represents the type of a recursion limit
@@ -79,13 +88,27 @@ func RecursionLimitNone() RecursionLimit {
 	return RecursionLimit{RecursionLimit_None, 0}
 }
 
+// StopAt returns the condition which halts this recursion, or nil if there is none
+func (s ExploreRecursive) StopAt() *Condition {
+	return s.stopAt
+}
+
 // Interests for ExploreRecursive is empty (meaning traverse everything)
 func (s ExploreRecursive) Interests() []ipld.PathSegment {
 	return s.current.Interests()
 }
 
-// Explore returns the node's selector for all fields
+// Explore returns the node's selector for all fields,
+// or nil if the stopAt condition holds on either the node or the explored child
 func (s ExploreRecursive) Explore(n ipld.Node, p ipld.PathSegment) Selector {
+	if s.stopped(n) {
+		return nil
+	}
+	if s.stopAt != nil {
+		if target, err := n.LookupSegment(p); err == nil && s.stopAt.Match(target) {
+			return nil
+		}
+	}
 	nextSelector := s.current.Explore(n, p)
 	limit := s.limit
 
@@ -93,16 +116,16 @@ func (s ExploreRecursive) Explore(n ipld.Node, p ipld.PathSegment) Selector {
 		return nil
 	}
 	if !s.hasRecursiveEdge(nextSelector) {
-		return ExploreRecursive{s.sequence, nextSelector, limit}
+		return ExploreRecursive{s.sequence, nextSelector, limit, s.stopAt}
 	}
 	switch limit.mode {
 	case RecursionLimit_Depth:
 		if limit.depth < 2 {
 			return s.replaceRecursiveEdge(nextSelector, nil)
 		}
-		return ExploreRecursive{s.sequence, s.replaceRecursiveEdge(nextSelector, s.sequence), RecursionLimit{RecursionLimit_Depth, limit.depth - 1}}
+		return ExploreRecursive{s.sequence, s.replaceRecursiveEdge(nextSelector, s.sequence), RecursionLimit{RecursionLimit_Depth, limit.depth - 1}, s.stopAt}
 	case RecursionLimit_None:
-		return ExploreRecursive{s.sequence, s.replaceRecursiveEdge(nextSelector, s.sequence), limit}
+		return ExploreRecursive{s.sequence, s.replaceRecursiveEdge(nextSelector, s.sequence), limit, s.stopAt}
 	default:
 		panic("Unsupported recursion limit type")
 	}
@@ -121,6 +144,10 @@ func (s ExploreRecursive) hasRecursiveEdge(nextSelector Selector) bool {
 			}
 		}
 	}
+	exploreConditional, isConditional := nextSelector.(ExploreConditional)
+	if isConditional {
+		return s.hasRecursiveEdge(exploreConditional.next)
+	}
 	return false
 }
 
@@ -146,14 +173,38 @@ func (s ExploreRecursive) replaceRecursiveEdge(nextSelector Selector, replacemen
 		}
 		return ExploreUnion{replacementMembers}
 	}
+	exploreConditional, isConditional := nextSelector.(ExploreConditional)
+	if isConditional {
+		newSelector := s.replaceRecursiveEdge(exploreConditional.next, replacement)
+		if newSelector == nil {
+			return nil
+		}
+		return ExploreConditional{exploreConditional.condition, newSelector}
+	}
 	return nextSelector
 }
 
-// Decide always returns false because this is not a matcher
+// Decide returns what the current selector decides,
+// unless the stopAt condition holds on the node
 func (s ExploreRecursive) Decide(n ipld.Node) bool {
+	if s.stopped(n) {
+		return false
+	}
 	return s.current.Decide(n)
 }
 
+// Labels returns the labels of the current selector
+func (s ExploreRecursive) Labels(n ipld.Node) []string {
+	if s.stopped(n) {
+		return nil
+	}
+	return Labels(s.current, n)
+}
+
+func (s ExploreRecursive) stopped(n ipld.Node) bool {
+	return s.stopAt != nil && s.stopAt.Match(n)
+}
+
 type exploreRecursiveContext struct {
 	edgesFound int
 }
@@ -192,7 +243,15 @@ func (pc ParseContext) ParseExploreRecursive(n ipld.Node) (Selector, error) {
 	if erc.edgesFound == 0 {
 		return nil, fmt.Errorf("selector spec parse rejected: ExploreRecursive must have at least one ExploreRecursiveEdge")
 	}
-	return ExploreRecursive{selector, selector, limit}, nil
+	var stopAt *Condition
+	if stopAtNode, err := n.LookupString(SelectorKey_StopAt); err == nil {
+		condition, err := pc.ParseCondition(stopAtNode)
+		if err != nil {
+			return nil, err
+		}
+		stopAt = &condition
+	}
+	return ExploreRecursive{selector, selector, limit, stopAt}, nil
 }
 
 func parseLimit(n ipld.Node) (RecursionLimit, error) {
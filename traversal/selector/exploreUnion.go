@@ -71,6 +71,16 @@ func (s ExploreUnion) Decide(n ipld.Node) bool {
 	return false
 }
 
+// Labels returns the labels of all member selectors which decide the node
+// is a match
+func (s ExploreUnion) Labels(n ipld.Node) []string {
+	var labels []string
+	for _, m := range s.Members {
+		labels = append(labels, Labels(m, n)...)
+	}
+	return labels
+}
+
 // ParseExploreUnion assembles a Selector
 // from an ExploreUnion selector node
 func (pc ParseContext) ParseExploreUnion(n ipld.Node) (Selector, error) {
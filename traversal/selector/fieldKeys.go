@@ -21,5 +21,14 @@ const (
 	SelectorKey_LimitNone            = "none"
 	SelectorKey_StopAt               = "!"
 	SelectorKey_Condition            = "&"
-	// not filling conditional keys since it's not complete
+	SelectorKey_Label                = "$"
+	SelectorKey_ConditionHasField    = "hasField"
+	SelectorKey_ConditionHasValue    = "="
+	SelectorKey_ConditionHasKind     = "%"
+	SelectorKey_ConditionIsLink      = "/"
+	SelectorKey_ConditionGreaterThan = "greaterThan"
+	SelectorKey_ConditionLessThan    = "lessThan"
+	SelectorKey_ConditionAnd         = "and"
+	SelectorKey_ConditionOr          = "or"
+	SelectorKey_FieldName            = "name"
 )
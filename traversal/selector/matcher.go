@@ -13,10 +13,34 @@ import (
 // In libraries using selectors, the "result" set is typically provided to
 // some user-specified callback.
 //
+// A Matcher may have a Condition, in which case only nodes for which the
+// condition holds are included in the result set.
+// A Matcher may also have a label, which is reported alongside the nodes it
+// matches (see Labels), so that one selection can gather several named groups
+// of results.
+//
 // A selector tree with only "explore*"-type selectors and no Matcher selectors
 // is valid; it will just generate a "covered" set of nodes and no "result" set.
-// TODO: From spec: implement conditions and labels
-type Matcher struct{}
+type Matcher struct {
+	onlyIf *Condition // if nil, the match is true based on position alone
+	label  string     // if empty, the matcher is unlabelled
+}
+
+// NewMatcher returns a Matcher with the given condition (nil meaning none)
+// and label (empty meaning none).
+func NewMatcher(onlyIf *Condition, label string) Matcher {
+	return Matcher{onlyIf, label}
+}
+
+// Condition returns the condition of this Matcher, or nil if it has none.
+func (s Matcher) Condition() *Condition {
+	return s.onlyIf
+}
+
+// Label returns the label of this Matcher, or "" if it has none.
+func (s Matcher) Label() string {
+	return s.label
+}
 
 // Interests are empty for a matcher (for now) because
 // It is always just there to match, not explore further
@@ -29,18 +53,44 @@ func (s Matcher) Explore(n ipld.Node, p ipld.PathSegment) Selector {
 	return nil
 }
 
-// Decide is always true for a match cause it's in the result set
-// TODO: Implement boolean logic for conditionals
+// Decide is true for a match cause it's in the result set,
+// as long as the condition (if any) holds on the node
 func (s Matcher) Decide(n ipld.Node) bool {
-	return true
+	if s.onlyIf == nil {
+		return true
+	}
+	return s.onlyIf.Match(n)
+}
+
+// Labels returns the label of this Matcher if it has one and decides
+// the node is a match
+func (s Matcher) Labels(n ipld.Node) []string {
+	if s.label == "" || !s.Decide(n) {
+		return nil
+	}
+	return []string{s.label}
 }
 
 // ParseMatcher assembles a Selector
 // from a matcher selector node
-// TODO: Parse labels and conditions
 func (pc ParseContext) ParseMatcher(n ipld.Node) (Selector, error) {
 	if n.ReprKind() != ipld.ReprKind_Map {
 		return nil, fmt.Errorf("selector spec parse rejected: selector body must be a map")
 	}
-	return Matcher{}, nil
+	x := Matcher{}
+	if conditionNode, err := n.LookupString(SelectorKey_Condition); err == nil {
+		c, err := pc.ParseCondition(conditionNode)
+		if err != nil {
+			return nil, err
+		}
+		x.onlyIf = &c
+	}
+	if labelNode, err := n.LookupString(SelectorKey_Label); err == nil {
+		label, err := labelNode.AsString()
+		if err != nil {
+			return nil, fmt.Errorf("selector spec parse rejected: label field must be a string in Matcher selector")
+		}
+		x.label = label
+	}
+	return x, nil
 }
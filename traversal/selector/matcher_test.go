@@ -0,0 +1,105 @@
+package selector
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+func TestParseMatcher(t *testing.T) {
+	t.Run("parsing non map node should error", func(t *testing.T) {
+		sn := basicnode.NewInt(0)
+		_, err := ParseContext{}.ParseMatcher(sn)
+		Wish(t, err, ShouldEqual, fmt.Errorf("selector spec parse rejected: selector body must be a map"))
+	})
+	t.Run("parsing empty map node should parse", func(t *testing.T) {
+		sn := fluent.MustBuildMap(basicnode.Style__Map{}, 0, func(na fluent.MapAssembler) {})
+		s, err := ParseContext{}.ParseMatcher(sn)
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, s, ShouldEqual, Matcher{})
+	})
+	t.Run("parsing map node with non-string label should error", func(t *testing.T) {
+		sn := fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(SelectorKey_Label).AssignInt(2)
+		})
+		_, err := ParseContext{}.ParseMatcher(sn)
+		Wish(t, err, ShouldEqual, fmt.Errorf("selector spec parse rejected: label field must be a string in Matcher selector"))
+	})
+	t.Run("parsing map node with invalid condition should return the condition's error", func(t *testing.T) {
+		sn := fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(SelectorKey_Condition).CreateMap(1, func(na fluent.MapAssembler) {
+				na.AssembleEntry("nope").AssignNull()
+			})
+		})
+		_, err := ParseContext{}.ParseMatcher(sn)
+		Wish(t, err, ShouldEqual, fmt.Errorf("selector spec parse rejected: \"nope\" is not a known member of the condition union"))
+	})
+	t.Run("parsing map node with condition and label should parse", func(t *testing.T) {
+		sn := fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+			na.AssembleEntry(SelectorKey_Condition).CreateMap(1, func(na fluent.MapAssembler) {
+				na.AssembleEntry(SelectorKey_ConditionHasKind).AssignString("string")
+			})
+			na.AssembleEntry(SelectorKey_Label).AssignString("strings")
+		})
+		s, err := ParseContext{}.ParseMatcher(sn)
+		Wish(t, err, ShouldEqual, nil)
+		cond := ConditionHasKind(ipld.ReprKind_String)
+		Wish(t, s, ShouldEqual, Matcher{&cond, "strings"})
+	})
+}
+
+func TestMatcherDecide(t *testing.T) {
+	dir := fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+		na.AssembleEntry("type").AssignString("dir")
+		na.AssembleEntry("size").AssignInt(12)
+	})
+	file := fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+		na.AssembleEntry("type").AssignString("file")
+		na.AssembleEntry("size").AssignInt(400)
+	})
+	t.Run("matcher without condition matches everything", func(t *testing.T) {
+		s := Matcher{}
+		Wish(t, s.Decide(dir), ShouldEqual, true)
+		Wish(t, s.Decide(basicnode.NewInt(1)), ShouldEqual, true)
+		Wish(t, Labels(s, dir), ShouldEqual, []string(nil))
+	})
+	t.Run("matcher with field condition matches only where it holds", func(t *testing.T) {
+		cond := ConditionFieldMatches("type", ConditionHasValue(basicnode.NewString("dir")))
+		s := NewMatcher(&cond, "dirs")
+		Wish(t, s.Decide(dir), ShouldEqual, true)
+		Wish(t, s.Decide(file), ShouldEqual, false)
+		Wish(t, s.Decide(basicnode.NewString("dir")), ShouldEqual, false)
+		Wish(t, Labels(s, dir), ShouldEqual, []string{"dirs"})
+		Wish(t, Labels(s, file), ShouldEqual, []string(nil))
+	})
+	t.Run("matcher with the zero condition matches nothing", func(t *testing.T) {
+		s := NewMatcher(&Condition{}, "")
+		Wish(t, s.Decide(dir), ShouldEqual, false)
+		Wish(t, s.Decide(basicnode.NewInt(1)), ShouldEqual, false)
+	})
+	t.Run("comparison conditions work across ints and floats", func(t *testing.T) {
+		Wish(t, ConditionGreaterThan(basicnode.NewInt(3)).Match(basicnode.NewFloat(3.5)), ShouldEqual, true)
+		Wish(t, ConditionLessThan(basicnode.NewInt(3)).Match(basicnode.NewInt(3)), ShouldEqual, false)
+		Wish(t, ConditionLessThan(basicnode.NewInt(3)).Match(basicnode.NewString("2")), ShouldEqual, false)
+		Wish(t, ConditionLessThan(basicnode.NewString("b")).Match(basicnode.NewString("a")), ShouldEqual, true)
+	})
+	t.Run("and and or conditions combine members", func(t *testing.T) {
+		big := ConditionFieldMatches("size", ConditionGreaterThan(basicnode.NewInt(100)))
+		isDir := ConditionFieldMatches("type", ConditionHasValue(basicnode.NewString("dir")))
+		Wish(t, ConditionAnd(big, isDir).Match(dir), ShouldEqual, false)
+		Wish(t, ConditionAnd(big, ConditionHasKind(ipld.ReprKind_Map)).Match(file), ShouldEqual, true)
+		Wish(t, ConditionOr(big, isDir).Match(dir), ShouldEqual, true)
+		Wish(t, ConditionOr(big, isDir).Match(basicnode.NewBool(true)), ShouldEqual, false)
+	})
+	t.Run("union reports labels of every matching member", func(t *testing.T) {
+		isDir := ConditionFieldMatches("type", ConditionHasValue(basicnode.NewString("dir")))
+		s := ExploreUnion{[]Selector{NewMatcher(nil, "all"), NewMatcher(&isDir, "dirs")}}
+		Wish(t, Labels(s, dir), ShouldEqual, []string{"all", "dirs"})
+		Wish(t, Labels(s, file), ShouldEqual, []string{"all"})
+	})
+}
@@ -0,0 +1,621 @@
+package selector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent"
+)
+
+// Normalize rewrites a selector Node into a canonical, minimal form which
+// selects exactly the same nodes, building the result using the given NodeStyle
+// (which, as with ParseTextNode, must be able to hold any kind).
+//
+// Two selectors which differ only in ways Normalize removes will normalize to
+// the same Node, so the result is suitable for comparing selectors and for use
+// as a cache key (e.g. via an encoding of the Node).
+// A normalized selector is also often cheaper to traverse with.
+//
+// The rewrites include:
+//
+//   - members of an ExploreUnion are flattened, deduplicated, and sorted,
+//     and a union of one member becomes that member;
+//   - ExploreAll members of a union are merged into one, as are ExploreFields
+//     members (with the selectors for a field they share becoming a union);
+//   - ExploreIndex and ExploreRange members of a union with the same next
+//     selector are merged into as few ranges as possible, and dropped entirely
+//     if an ExploreAll member of the union already applies that selector;
+//     likewise fields of ExploreFields;
+//   - an ExploreRange covering a single index becomes an ExploreIndex;
+//   - an ExploreConditional of a Matcher becomes a Matcher with a condition,
+//     and nested ExploreConditionals are combined;
+//   - fields of an ExploreFields are sorted;
+//   - "and" and "or" conditions are flattened, deduplicated, and sorted,
+//     and those with one member become that member.
+//
+// Since union members are reordered, labels (see Labels) may be reported in a
+// different order by the normalized selector, and a label which appeared
+// more than once may be reported only once.
+// Likewise the fields of an ExploreFields may be visited in a different order.
+//
+// The selector is parsed first, and any error from parsing it is returned.
+func Normalize(n ipld.Node, ns ipld.NodeStyle) (ipld.Node, error) {
+	if _, err := ParseSelector(n); err != nil {
+		return nil, err
+	}
+	nz := normalizer{nodeMaker{ns}}
+	var result ipld.Node
+	err := fluent.Recover(func() {
+		result = nz.selector(n)
+	})
+	if err != nil {
+		return nil, err.(fluent.Error).Err
+	}
+	return result, nil
+}
+
+// NormalizeSelector is Normalize for an already-parsed Selector:
+// it returns the Selector parsed from the normalized form of the selector
+// Node that s was parsed from.  The intermediate Nodes are built using the
+// given NodeStyle, as with Normalize.
+//
+// An ExploreRecursive which is partway through its recursion (i.e. one
+// returned by Explore, rather than by parsing) can't be normalized, since
+// there's no selector Node which parses to it; this returns an error.
+func NormalizeSelector(s Selector, ns ipld.NodeStyle) (Selector, error) {
+	nm := nodeMaker{ns}
+	var n ipld.Node
+	err := fluent.Recover(func() {
+		n = nm.selectorNode(s)
+	})
+	if err != nil {
+		return nil, err.(fluent.Error).Err
+	}
+	n, err = Normalize(n, ns)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSelector(n)
+}
+
+type normalizer struct {
+	nodeMaker
+}
+
+// The helpers below panic with fluent.Error on failure, as nodeMaker does;
+// since Normalize parses the selector first, they're not expected to.
+
+func mustMember(n ipld.Node, what string) (string, ipld.Node) {
+	k, v, err := unionMember(n, what)
+	if err != nil {
+		panic(fluent.Error{Err: err})
+	}
+	return k, v
+}
+
+func mustInt(n ipld.Node) int {
+	i, err := n.AsInt()
+	if err != nil {
+		panic(fluent.Error{Err: err})
+	}
+	return i
+}
+
+func mustString(n ipld.Node) string {
+	s, err := n.AsString()
+	if err != nil {
+		panic(fluent.Error{Err: err})
+	}
+	return s
+}
+
+// nodeKey returns a key for a selector or condition Node, used to order and
+// compare them: two Nodes have the same key exactly when they're equal.
+// (The text syntax would be more readable, but can't express every value a
+// condition may hold, e.g. links and bytes; and using a codec here would make
+// this package depend on one.)
+func nodeKey(n ipld.Node) string {
+	var buf bytes.Buffer
+	writeNodeKey(&buf, n)
+	return buf.String()
+}
+
+// writeNodeKey writes the kind of a Node, then its contents.  Strings, bytes,
+// and collections are prefixed with their length, so the key of each Node
+// ends unambiguously, and map entries are sorted, so their order is ignored.
+func writeNodeKey(buf *bytes.Buffer, n ipld.Node) {
+	buf.WriteByte(byte(n.ReprKind()))
+	switch n.ReprKind() {
+	case ipld.ReprKind_Map:
+		entries := make([]string, 0, n.Length())
+		for itr := n.MapIterator(); !itr.Done(); {
+			k, v, err := itr.Next()
+			if err != nil {
+				panic(fluent.Error{Err: err})
+			}
+			var entry bytes.Buffer
+			writeNodeKey(&entry, k)
+			writeNodeKey(&entry, v)
+			entries = append(entries, entry.String())
+		}
+		sort.Strings(entries)
+		writeKeyLength(buf, len(entries))
+		for _, entry := range entries {
+			buf.WriteString(entry)
+		}
+	case ipld.ReprKind_List:
+		writeKeyLength(buf, n.Length())
+		for itr := n.ListIterator(); !itr.Done(); {
+			_, v, err := itr.Next()
+			if err != nil {
+				panic(fluent.Error{Err: err})
+			}
+			writeNodeKey(buf, v)
+		}
+	case ipld.ReprKind_Bool:
+		b, _ := n.AsBool()
+		if b {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case ipld.ReprKind_Int:
+		i, _ := n.AsInt()
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], uint64(i)^(1<<63)) // flip the sign bit, so keys sort like the ints.
+		buf.Write(b[:])
+	case ipld.ReprKind_Float:
+		f, _ := n.AsFloat()
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(f))
+		buf.Write(b[:])
+	case ipld.ReprKind_String:
+		s, _ := n.AsString()
+		writeKeyLength(buf, len(s))
+		buf.WriteString(s)
+	case ipld.ReprKind_Bytes:
+		b, _ := n.AsBytes()
+		writeKeyLength(buf, len(b))
+		buf.Write(b)
+	case ipld.ReprKind_Link:
+		l, _ := n.AsLink()
+		writeKeyLength(buf, len(l.String()))
+		buf.WriteString(l.String())
+	}
+}
+
+func writeKeyLength(buf *bytes.Buffer, length int) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], uint64(length))])
+}
+
+func (nz normalizer) selector(n ipld.Node) ipld.Node {
+	k, v := mustMember(n, "selector")
+	switch k {
+	case SelectorKey_Matcher:
+		var onlyIf ipld.Node
+		if c := lookupOptional(v, SelectorKey_Condition); c != nil {
+			onlyIf = nz.condition(c)
+		}
+		label := ""
+		if l := lookupOptional(v, SelectorKey_Label); l != nil {
+			label = mustString(l)
+		}
+		return nz.matcher(onlyIf, label)
+	case SelectorKey_ExploreAll:
+		return nz.exploreAll(nz.selector(lookupOptional(v, SelectorKey_Next)))
+	case SelectorKey_ExploreFields:
+		fields := map[string]ipld.Node{}
+		for itr := lookupOptional(v, SelectorKey_Fields).MapIterator(); !itr.Done(); {
+			kn, next, err := itr.Next()
+			if err != nil {
+				panic(fluent.Error{Err: err})
+			}
+			fields[mustString(kn)] = nz.selector(next)
+		}
+		return nz.exploreFields(fields)
+	case SelectorKey_ExploreIndex:
+		index := mustInt(lookupOptional(v, SelectorKey_Index))
+		return nz.exploreRange(index, index+1, nz.selector(lookupOptional(v, SelectorKey_Next)))
+	case SelectorKey_ExploreRange:
+		start := mustInt(lookupOptional(v, SelectorKey_Start))
+		end := mustInt(lookupOptional(v, SelectorKey_End))
+		return nz.exploreRange(start, end, nz.selector(lookupOptional(v, SelectorKey_Next)))
+	case SelectorKey_ExploreUnion:
+		var members []ipld.Node
+		for itr := v.ListIterator(); !itr.Done(); {
+			_, member, err := itr.Next()
+			if err != nil {
+				panic(fluent.Error{Err: err})
+			}
+			members = append(members, nz.selector(member))
+		}
+		return nz.union(members)
+	case SelectorKey_ExploreRecursive:
+		var limit ipld.Node
+		switch lk, lv := mustMember(lookupOptional(v, SelectorKey_Limit), "limit"); lk {
+		case SelectorKey_LimitDepth:
+			limit = nz.buildMap(entry{lk, nz.newInt(mustInt(lv))})
+		default:
+			limit = nz.buildMap(entry{lk, nz.emptyMap()})
+		}
+		entries := []entry{
+			{SelectorKey_Limit, limit},
+			{SelectorKey_Sequence, nz.selector(lookupOptional(v, SelectorKey_Sequence))},
+		}
+		if stopAt := lookupOptional(v, SelectorKey_StopAt); stopAt != nil {
+			entries = append(entries, entry{SelectorKey_StopAt, nz.condition(stopAt)})
+		}
+		return nz.buildMap(entry{k, nz.buildMap(entries...)})
+	case SelectorKey_ExploreRecursiveEdge:
+		return nz.buildMap(entry{k, nz.emptyMap()})
+	case SelectorKey_ExploreConditional:
+		return nz.exploreConditional(
+			nz.condition(lookupOptional(v, SelectorKey_Condition)),
+			nz.selector(lookupOptional(v, SelectorKey_Next)),
+		)
+	default:
+		panic(fluent.Error{Err: fmt.Errorf("selector normalize rejected: %q is not a known member of the selector union", k)})
+	}
+}
+
+func (nz normalizer) matcher(onlyIf ipld.Node, label string) ipld.Node {
+	var entries []entry
+	if onlyIf != nil {
+		entries = append(entries, entry{SelectorKey_Condition, onlyIf})
+	}
+	if label != "" {
+		entries = append(entries, entry{SelectorKey_Label, nz.newString(label)})
+	}
+	return nz.buildMap(entry{SelectorKey_Matcher, nz.buildMap(entries...)})
+}
+
+func (nz normalizer) exploreAll(next ipld.Node) ipld.Node {
+	return nz.buildMap(entry{SelectorKey_ExploreAll, nz.buildMap(entry{SelectorKey_Next, next})})
+}
+
+func (nz normalizer) exploreFields(fields map[string]ipld.Node) ipld.Node {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := make([]entry, len(names))
+	for i, name := range names {
+		entries[i] = entry{name, fields[name]}
+	}
+	return nz.buildMap(entry{SelectorKey_ExploreFields, nz.buildMap(entry{SelectorKey_Fields, nz.buildMap(entries...)})})
+}
+
+// exploreRange builds an ExploreRange, or an ExploreIndex if the range covers
+// a single index.
+func (nz normalizer) exploreRange(start, end int, next ipld.Node) ipld.Node {
+	if end == start+1 {
+		return nz.buildMap(entry{SelectorKey_ExploreIndex, nz.buildMap(
+			entry{SelectorKey_Index, nz.newInt(start)},
+			entry{SelectorKey_Next, next},
+		)})
+	}
+	return nz.buildMap(entry{SelectorKey_ExploreRange, nz.buildMap(
+		entry{SelectorKey_Start, nz.newInt(start)},
+		entry{SelectorKey_End, nz.newInt(end)},
+		entry{SelectorKey_Next, next},
+	)})
+}
+
+// exploreConditional builds an ExploreConditional, folding it into the next
+// selector where that is a Matcher or another ExploreConditional.
+func (nz normalizer) exploreConditional(condition, next ipld.Node) ipld.Node {
+	nk, nv := mustMember(next, "selector")
+	switch nk {
+	case SelectorKey_Matcher:
+		if onlyIf := lookupOptional(nv, SelectorKey_Condition); onlyIf != nil {
+			condition = nz.combine(SelectorKey_ConditionAnd, []ipld.Node{condition, onlyIf})
+		}
+		label := ""
+		if l := lookupOptional(nv, SelectorKey_Label); l != nil {
+			label = mustString(l)
+		}
+		return nz.matcher(condition, label)
+	case SelectorKey_ExploreConditional:
+		return nz.exploreConditional(
+			nz.combine(SelectorKey_ConditionAnd, []ipld.Node{condition, lookupOptional(nv, SelectorKey_Condition)}),
+			lookupOptional(nv, SelectorKey_Next),
+		)
+	}
+	return nz.buildMap(entry{SelectorKey_ExploreConditional, nz.buildMap(
+		entry{SelectorKey_Condition, condition},
+		entry{SelectorKey_Next, next},
+	)})
+}
+
+// indexRange is a half-open range of list indexes.
+type indexRange struct {
+	start, end int
+}
+
+// union builds the normalized union of some already-normalized selectors.
+func (nz normalizer) union(members []ipld.Node) ipld.Node {
+	var (
+		alls       []ipld.Node
+		fields     = map[string][]ipld.Node{}
+		ranges     = map[string][]indexRange{}
+		rangeNexts = map[string]ipld.Node{}
+		others     []ipld.Node
+	)
+	var gather func(members []ipld.Node)
+	gather = func(members []ipld.Node) {
+		for _, m := range members {
+			k, v := mustMember(m, "selector")
+			switch k {
+			case SelectorKey_ExploreUnion:
+				var nested []ipld.Node
+				for itr := v.ListIterator(); !itr.Done(); {
+					_, member, err := itr.Next()
+					if err != nil {
+						panic(fluent.Error{Err: err})
+					}
+					nested = append(nested, member)
+				}
+				gather(nested)
+			case SelectorKey_ExploreAll:
+				alls = append(alls, lookupOptional(v, SelectorKey_Next))
+			case SelectorKey_ExploreFields:
+				for itr := lookupOptional(v, SelectorKey_Fields).MapIterator(); !itr.Done(); {
+					kn, next, err := itr.Next()
+					if err != nil {
+						panic(fluent.Error{Err: err})
+					}
+					name := mustString(kn)
+					fields[name] = append(fields[name], next)
+				}
+			case SelectorKey_ExploreIndex, SelectorKey_ExploreRange:
+				next := lookupOptional(v, SelectorKey_Next)
+				r := indexRange{}
+				if k == SelectorKey_ExploreIndex {
+					r.start = mustInt(lookupOptional(v, SelectorKey_Index))
+					r.end = r.start + 1
+				} else {
+					r.start = mustInt(lookupOptional(v, SelectorKey_Start))
+					r.end = mustInt(lookupOptional(v, SelectorKey_End))
+				}
+				key := nodeKey(next)
+				ranges[key] = append(ranges[key], r)
+				rangeNexts[key] = next
+			default:
+				others = append(others, m)
+			}
+		}
+	}
+	gather(members)
+
+	var result []ipld.Node
+	allKey := ""
+	if len(alls) > 0 {
+		allNext := nz.union(alls)
+		allKey = nodeKey(allNext)
+		result = append(result, nz.exploreAll(allNext))
+	}
+	merged := map[string]ipld.Node{}
+	for name, nexts := range fields {
+		next := nz.union(nexts)
+		if len(alls) > 0 && nodeKey(next) == allKey {
+			continue
+		}
+		merged[name] = next
+	}
+	if len(merged) > 0 {
+		result = append(result, nz.exploreFields(merged))
+	}
+	for key, rs := range ranges {
+		if len(alls) > 0 && key == allKey {
+			continue
+		}
+		sort.Slice(rs, func(i, j int) bool { return rs[i].start < rs[j].start })
+		cur := rs[0]
+		for _, r := range rs[1:] {
+			if r.start <= cur.end {
+				if r.end > cur.end {
+					cur.end = r.end
+				}
+				continue
+			}
+			result = append(result, nz.exploreRange(cur.start, cur.end, rangeNexts[key]))
+			cur = r
+		}
+		result = append(result, nz.exploreRange(cur.start, cur.end, rangeNexts[key]))
+	}
+	result = append(result, others...)
+
+	result = sortDedup(result, nodeKey)
+	if len(result) == 1 {
+		return result[0]
+	}
+	return nz.buildMap(entry{SelectorKey_ExploreUnion, nz.buildList(result)})
+}
+
+// sortDedup sorts nodes by their keys and removes those with duplicate keys.
+func sortDedup(nodes []ipld.Node, key func(ipld.Node) string) []ipld.Node {
+	type keyed struct {
+		key string
+		n   ipld.Node
+	}
+	ks := make([]keyed, len(nodes))
+	for i, n := range nodes {
+		ks[i] = keyed{key(n), n}
+	}
+	sort.SliceStable(ks, func(i, j int) bool { return ks[i].key < ks[j].key })
+	result := make([]ipld.Node, 0, len(ks))
+	for i, k := range ks {
+		if i > 0 && k.key == ks[i-1].key {
+			continue
+		}
+		result = append(result, k.n)
+	}
+	return result
+}
+
+func (nz normalizer) condition(n ipld.Node) ipld.Node {
+	k, v := mustMember(n, "condition")
+	switch k {
+	case SelectorKey_ConditionHasField:
+		entries := []entry{{SelectorKey_FieldName, nz.newString(mustString(lookupOptional(v, SelectorKey_FieldName)))}}
+		if sub := lookupOptional(v, SelectorKey_Condition); sub != nil {
+			entries = append(entries, entry{SelectorKey_Condition, nz.condition(sub)})
+		}
+		return nz.buildMap(entry{k, nz.buildMap(entries...)})
+	case SelectorKey_ConditionHasValue, SelectorKey_ConditionGreaterThan, SelectorKey_ConditionLessThan:
+		return nz.buildMap(entry{k, v})
+	case SelectorKey_ConditionHasKind:
+		kind, _ := parseReprKind(mustString(v))
+		return nz.buildMap(entry{k, nz.newString(kind.String())})
+	case SelectorKey_ConditionIsLink:
+		return nz.buildMap(entry{k, nz.emptyMap()})
+	case SelectorKey_ConditionAnd, SelectorKey_ConditionOr:
+		var members []ipld.Node
+		for itr := v.ListIterator(); !itr.Done(); {
+			_, member, err := itr.Next()
+			if err != nil {
+				panic(fluent.Error{Err: err})
+			}
+			members = append(members, nz.condition(member))
+		}
+		return nz.combine(k, members)
+	default:
+		panic(fluent.Error{Err: fmt.Errorf("selector normalize rejected: %q is not a known member of the condition union", k)})
+	}
+}
+
+// combine builds the normalized "and" or "or" of some already-normalized conditions.
+func (nz normalizer) combine(op string, members []ipld.Node) ipld.Node {
+	var flat []ipld.Node
+	for _, m := range members {
+		if k, v := mustMember(m, "condition"); k == op {
+			for itr := v.ListIterator(); !itr.Done(); {
+				_, member, err := itr.Next()
+				if err != nil {
+					panic(fluent.Error{Err: err})
+				}
+				flat = append(flat, member)
+			}
+			continue
+		}
+		flat = append(flat, m)
+	}
+	flat = sortDedup(flat, nodeKey)
+	if len(flat) == 1 {
+		return flat[0]
+	}
+	return nz.buildMap(entry{op, nz.buildList(flat)})
+}
+
+// selectorNode builds the selector Node that a Selector was parsed from.
+func (nm nodeMaker) selectorNode(s Selector) ipld.Node {
+	switch s := s.(type) {
+	case Matcher:
+		var entries []entry
+		if s.onlyIf != nil {
+			entries = append(entries, entry{SelectorKey_Condition, nm.conditionNode(*s.onlyIf)})
+		}
+		if s.label != "" {
+			entries = append(entries, entry{SelectorKey_Label, nm.newString(s.label)})
+		}
+		return nm.buildMap(entry{SelectorKey_Matcher, nm.buildMap(entries...)})
+	case ExploreAll:
+		return nm.buildMap(entry{SelectorKey_ExploreAll, nm.buildMap(entry{SelectorKey_Next, nm.selectorNode(s.next)})})
+	case ExploreFields:
+		entries := make([]entry, len(s.interests))
+		for i, ps := range s.interests {
+			entries[i] = entry{ps.String(), nm.selectorNode(s.selections[ps.String()])}
+		}
+		return nm.buildMap(entry{SelectorKey_ExploreFields, nm.buildMap(entry{SelectorKey_Fields, nm.buildMap(entries...)})})
+	case ExploreIndex:
+		index, err := s.interest[0].Index()
+		if err != nil {
+			panic(fluent.Error{Err: err})
+		}
+		return nm.buildMap(entry{SelectorKey_ExploreIndex, nm.buildMap(
+			entry{SelectorKey_Index, nm.newInt(index)},
+			entry{SelectorKey_Next, nm.selectorNode(s.next)},
+		)})
+	case ExploreRange:
+		return nm.buildMap(entry{SelectorKey_ExploreRange, nm.buildMap(
+			entry{SelectorKey_Start, nm.newInt(s.start)},
+			entry{SelectorKey_End, nm.newInt(s.end)},
+			entry{SelectorKey_Next, nm.selectorNode(s.next)},
+		)})
+	case ExploreUnion:
+		members := make([]ipld.Node, len(s.Members))
+		for i, m := range s.Members {
+			members[i] = nm.selectorNode(m)
+		}
+		return nm.buildMap(entry{SelectorKey_ExploreUnion, nm.buildList(members)})
+	case ExploreRecursive:
+		if !reflect.DeepEqual(s.current, s.sequence) {
+			panic(fluent.Error{Err: fmt.Errorf("selector normalize rejected: ExploreRecursive is partway through its recursion")})
+		}
+		var limit ipld.Node
+		switch s.limit.mode {
+		case RecursionLimit_Depth:
+			limit = nm.buildMap(entry{SelectorKey_LimitDepth, nm.newInt(s.limit.depth)})
+		default:
+			limit = nm.buildMap(entry{SelectorKey_LimitNone, nm.emptyMap()})
+		}
+		entries := []entry{
+			{SelectorKey_Limit, limit},
+			{SelectorKey_Sequence, nm.selectorNode(s.sequence)},
+		}
+		if s.stopAt != nil {
+			entries = append(entries, entry{SelectorKey_StopAt, nm.conditionNode(*s.stopAt)})
+		}
+		return nm.buildMap(entry{SelectorKey_ExploreRecursive, nm.buildMap(entries...)})
+	case ExploreRecursiveEdge:
+		return nm.buildMap(entry{SelectorKey_ExploreRecursiveEdge, nm.emptyMap()})
+	case ExploreConditional:
+		return nm.buildMap(entry{SelectorKey_ExploreConditional, nm.buildMap(
+			entry{SelectorKey_Condition, nm.conditionNode(s.condition)},
+			entry{SelectorKey_Next, nm.selectorNode(s.next)},
+		)})
+	default:
+		panic(fluent.Error{Err: fmt.Errorf("selector normalize rejected: unknown selector type %T", s)})
+	}
+}
+
+// conditionNode builds the condition Node that a Condition was parsed from.
+func (nm nodeMaker) conditionNode(c Condition) ipld.Node {
+	switch c.mode {
+	case ConditionMode_HasField:
+		entries := []entry{{SelectorKey_FieldName, nm.newString(c.fieldName)}}
+		for _, sub := range c.conditions {
+			entries = append(entries, entry{SelectorKey_Condition, nm.conditionNode(sub)})
+		}
+		return nm.buildMap(entry{SelectorKey_ConditionHasField, nm.buildMap(entries...)})
+	case ConditionMode_HasValue:
+		return nm.buildMap(entry{SelectorKey_ConditionHasValue, c.value})
+	case ConditionMode_HasKind:
+		return nm.buildMap(entry{SelectorKey_ConditionHasKind, nm.newString(c.kind.String())})
+	case ConditionMode_IsLink:
+		return nm.buildMap(entry{SelectorKey_ConditionIsLink, nm.emptyMap()})
+	case ConditionMode_GreaterThan:
+		return nm.buildMap(entry{SelectorKey_ConditionGreaterThan, c.value})
+	case ConditionMode_LessThan:
+		return nm.buildMap(entry{SelectorKey_ConditionLessThan, c.value})
+	case ConditionMode_And, ConditionMode_Or:
+		k := SelectorKey_ConditionAnd
+		if c.mode == ConditionMode_Or {
+			k = SelectorKey_ConditionOr
+		}
+		members := make([]ipld.Node, len(c.conditions))
+		for i, sub := range c.conditions {
+			members[i] = nm.conditionNode(sub)
+		}
+		return nm.buildMap(entry{k, nm.buildList(members)})
+	default:
+		panic(fluent.Error{Err: fmt.Errorf("selector normalize rejected: invalid condition")})
+	}
+}
@@ -0,0 +1,127 @@
+package selector
+
+import (
+	"testing"
+
+	cid "github.com/ipfs/go-cid"
+	. "github.com/warpfork/go-wish"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+func TestNormalize(t *testing.T) {
+	normalizeText := func(t *testing.T, text string) string {
+		sn, err := ParseTextNode(text, basicnode.Style__Any{})
+		Require(t, err, ShouldEqual, nil)
+		normalized, err := Normalize(sn, basicnode.Style__Any{})
+		Require(t, err, ShouldEqual, nil)
+		_, err = ParseSelector(normalized)
+		Wish(t, err, ShouldEqual, nil)
+		result, err := PrintText(normalized)
+		Require(t, err, ShouldEqual, nil)
+		return result
+	}
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"already minimal selector is unchanged",
+			`recursive(depth=3, all(union(match, edge)), stopAt=isLink)`,
+			`recursive(depth=3, all(union(match, edge)), stopAt=isLink)`},
+		{"single index range becomes index",
+			`range(2, 3, match)`,
+			`index(2, match)`},
+		{"single member union becomes member",
+			`union(all(match))`,
+			`all(match)`},
+		{"duplicate union members are removed",
+			`union(match, fields(a=match), match)`,
+			`union(match, fields(a=match))`},
+		{"nested unions are flattened",
+			`union(match, union(index(0, match), union(match)))`,
+			`union(match, index(0, match))`},
+		{"fields are sorted",
+			`fields(b=match, a=match)`,
+			`fields(a=match, b=match)`},
+		{"fields members are merged",
+			`union(fields(a=match, b=match), fields(b=all(match), c=match))`,
+			`fields(a=match, b=union(match, all(match)), c=match)`},
+		{"all members are merged",
+			`union(all(match), all(fields(x=match)))`,
+			`all(union(match, fields(x=match)))`},
+		{"fields and indexes covered by all are dropped",
+			`union(all(match), fields(a=match, b=all(match)), index(1, match), range(0, 5, match))`,
+			`union(all(match), fields(b=all(match)))`},
+		{"overlapping and adjacent ranges are merged",
+			`union(range(0, 3, match), index(3, match), range(2, 4, match), index(7, match), index(6, match), index(9, all(match)))`,
+			`union(index(9, all(match)), range(0, 4, match), range(6, 8, match))`},
+		{"conditional matcher becomes matcher with condition",
+			`if(isLink, match(if=kind(map), label="x"))`,
+			`match(if=and(kind(map), isLink), label="x")`},
+		{"nested conditionals are combined",
+			`if(isLink, if(kind(map), all(match)))`,
+			`if(and(kind(map), isLink), all(match))`},
+		{"conditions are flattened and deduplicated",
+			`match(if=or(eq(1), or(eq(2), eq(1)), and(isLink)))`,
+			`match(if=or(isLink, eq(1), eq(2)))`},
+		{"empty label is dropped",
+			`match(label="")`,
+			`match`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			Wish(t, normalizeText(t, tc.input), ShouldEqual, tc.expected)
+			Wish(t, normalizeText(t, tc.expected), ShouldEqual, tc.expected)
+		})
+	}
+	t.Run("invalid selector should error", func(t *testing.T) {
+		sn, err := ParseTextNode(`recursive(depth=1, match)`, basicnode.Style__Any{})
+		Require(t, err, ShouldEqual, nil)
+		_, err = Normalize(sn, basicnode.Style__Any{})
+		Wish(t, err == nil, ShouldEqual, false)
+	})
+}
+
+func TestNormalizeSelector(t *testing.T) {
+	c, _ := cid.Decode("bafyreiejkvsvdq4smz44yuwhfymcuvqzavveoj2at3utujwqlllspsqr6q")
+	onLink := ConditionHasValue(basicnode.NewLink(cidlink.Link{Cid: c}))
+	onBytes := ConditionHasValue(basicnode.NewBytes([]byte{0x01, 0x02}))
+	t.Run("link and bytes values should normalize", func(t *testing.T) {
+		a, err := NormalizeSelector(ExploreUnion{[]Selector{
+			NewMatcher(&onBytes, ""),
+			NewMatcher(&onLink, ""),
+			NewMatcher(&onBytes, ""),
+		}}, basicnode.Style__Any{})
+		Require(t, err, ShouldEqual, nil)
+		b, err := NormalizeSelector(ExploreUnion{[]Selector{
+			NewMatcher(&onLink, ""),
+			NewMatcher(&onBytes, ""),
+		}}, basicnode.Style__Any{})
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, a, ShouldEqual, b)
+		Wish(t, len(a.(ExploreUnion).Members), ShouldEqual, 2)
+	})
+	t.Run("parsed selectors should normalize as their nodes do", func(t *testing.T) {
+		s, err := ParseSelector(mustParseTextNode(t, `recursive(none, union(range(2, 3, match), all(edge)))`))
+		Require(t, err, ShouldEqual, nil)
+		normalized, err := NormalizeSelector(ExploreAll{s}, basicnode.Style__Any{})
+		Require(t, err, ShouldEqual, nil)
+		expected, err := ParseSelector(mustParseTextNode(t, `all(recursive(none, union(all(edge), index(2, match))))`))
+		Require(t, err, ShouldEqual, nil)
+		Wish(t, normalized, ShouldEqual, expected)
+	})
+	t.Run("a recursion partway through should error", func(t *testing.T) {
+		s, err := ParseSelector(mustParseTextNode(t, `recursive(none, fields(a=fields(b=edge)))`))
+		Require(t, err, ShouldEqual, nil)
+		_, err = NormalizeSelector(s.Explore(basicnode.NewString("x"), ipld.PathSegmentOfString("a")), basicnode.Style__Any{})
+		Wish(t, err == nil, ShouldEqual, false)
+	})
+}
+
+func mustParseTextNode(t *testing.T, text string) ipld.Node {
+	n, err := ParseTextNode(text, basicnode.Style__Any{})
+	Require(t, err, ShouldEqual, nil)
+	return n
+}
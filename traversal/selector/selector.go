@@ -14,6 +14,22 @@ type Selector interface {
 	Decide(ipld.Node) bool
 }
 
+// Labeller is implemented by Selectors which can report the labels of the
+// Matchers (see Matcher) that selected a node.
+type Labeller interface {
+	Labels(ipld.Node) []string
+}
+
+// Labels returns the labels of the Matchers within the given Selector
+// which decide the given node is a match, or nil if there are none
+// (or if the Selector does not implement Labeller).
+func Labels(s Selector, n ipld.Node) []string {
+	if l, ok := s.(Labeller); ok {
+		return l.Labels(n)
+	}
+	return nil
+}
+
 // ParsedParent is created whenever you are parsing a selector node that may have
 // child selectors nodes that need to know it
 type ParsedParent interface {
@@ -57,6 +73,8 @@ func (pc ParseContext) ParseSelector(n ipld.Node) (Selector, error) {
 		return pc.ParseExploreRecursive(v)
 	case SelectorKey_ExploreRecursiveEdge:
 		return pc.ParseExploreRecursiveEdge(v)
+	case SelectorKey_ExploreConditional:
+		return pc.ParseExploreConditional(v)
 	case SelectorKey_Matcher:
 		return pc.ParseMatcher(v)
 	default:
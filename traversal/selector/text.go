@@ -0,0 +1,991 @@
+package selector
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent"
+)
+
+// The text syntax for selectors is a compact, human-writable alternative to
+// the selector Node form; it's meant for places like CLI flags and config files.
+// Every selector in text syntax corresponds to exactly one selector Node,
+// and ParseTextNode and PrintText convert between the two.
+//
+// A selector is written as one of:
+//
+//	match                              -- Matcher
+//	match(if=COND, label="name")       -- Matcher with a condition and/or label (both optional)
+//	all(SEL)                           -- ExploreAll
+//	fields(foo=SEL, "b a r"=SEL)       -- ExploreFields (names may be quoted)
+//	index(2, SEL)                      -- ExploreIndex
+//	range(1, 4, SEL)                   -- ExploreRange
+//	union(SEL, SEL, ...)               -- ExploreUnion
+//	recursive(depth=5, SEL)            -- ExploreRecursive with a depth limit
+//	recursive(none, SEL, stopAt=COND)  -- ExploreRecursive with no limit (stopAt is optional)
+//	edge                               -- ExploreRecursiveEdge
+//	if(COND, SEL)                      -- ExploreConditional
+//
+// A condition is written as one of:
+//
+//	hasField(foo)                      -- the node is a map with the field
+//	hasField(foo, COND)                -- ... and the condition holds on the field's value
+//	eq(VAL), gt(VAL), lt(VAL)          -- comparisons with a value
+//	kind(string)                       -- the node is of the given kind
+//	isLink                             -- the node is a link
+//	and(COND, ...), or(COND, ...)      -- combinations
+//
+// Values are written as quoted strings (with Go escaping rules), ints, floats,
+// true, false, or null.  A number is a float if it contains '.', 'e', or 'E',
+// and is otherwise an int (which must fit in a Go int).
+// Whitespace between tokens is ignored.
+//
+// For example, `recursive(depth=5, union(match, all(edge)))` selects
+// everything down to five levels deep.
+//
+// To parse text straight into a Selector, use builder.ParseText.
+// (It lives there, rather than here, because it builds with basicnode,
+// which this package can't import.)
+
+// ParseTextNode parses a selector in text syntax and returns the selector Node
+// it corresponds to, built using the given NodeStyle.
+// The NodeStyle is used for every node in the selector, so it must be able to
+// hold any kind (e.g. basicnode.Style__Any).
+//
+// Only the syntax is checked here; use ParseSelector (or builder.ParseText) to
+// check the semantics of the resulting selector as well.
+func ParseTextNode(text string, ns ipld.NodeStyle) (ipld.Node, error) {
+	p := &textParser{text: text, nodeMaker: nodeMaker{ns}}
+	var n ipld.Node
+	err := fluent.Recover(func() {
+		var err error
+		n, err = p.parseSelector()
+		if err != nil {
+			panic(fluent.Error{Err: err})
+		}
+		p.skipSpace()
+		if p.pos != len(p.text) {
+			panic(fluent.Error{Err: p.errorf("unexpected trailing text")})
+		}
+	})
+	if err != nil {
+		return nil, err.(fluent.Error).Err
+	}
+	return n, nil
+}
+
+type textParser struct {
+	nodeMaker
+	text string
+	pos  int
+}
+
+func (p *textParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("selector text parse rejected at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *textParser) skipSpace() {
+	for p.pos < len(p.text) {
+		switch p.text[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next non-space byte, or 0 at the end of the text.
+func (p *textParser) peek() byte {
+	p.skipSpace()
+	if p.pos == len(p.text) {
+		return 0
+	}
+	return p.text[p.pos]
+}
+
+// accept consumes the given punctuation byte if it's next, and reports whether it did.
+func (p *textParser) accept(c byte) bool {
+	if p.peek() != c {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+func (p *textParser) expect(c byte) error {
+	if !p.accept(c) {
+		return p.errorf("expected %q", c)
+	}
+	return nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func (p *textParser) ident() (string, error) {
+	p.skipSpace()
+	start := p.pos
+	if p.pos == len(p.text) || !isIdentStart(p.text[p.pos]) {
+		return "", p.errorf("expected a name")
+	}
+	for p.pos < len(p.text) && isIdentPart(p.text[p.pos]) {
+		p.pos++
+	}
+	return p.text[start:p.pos], nil
+}
+
+// keyword consumes the given identifier followed by '=' if they're next, and reports whether it did.
+func (p *textParser) keyword(kw string) bool {
+	save := p.pos
+	if id, err := p.ident(); err == nil && id == kw && p.accept('=') {
+		return true
+	}
+	p.pos = save
+	return false
+}
+
+func (p *textParser) quoted() (string, error) {
+	p.skipSpace()
+	if p.pos == len(p.text) || p.text[p.pos] != '"' {
+		return "", p.errorf("expected a quoted string")
+	}
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.text) && p.text[p.pos] != '"' {
+		if p.text[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.text) {
+		p.pos = start
+		return "", p.errorf("unterminated quoted string")
+	}
+	p.pos++
+	s, err := strconv.Unquote(p.text[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return "", p.errorf("invalid quoted string: %s", err)
+	}
+	return s, nil
+}
+
+// name parses a field name, which is either an identifier or a quoted string.
+func (p *textParser) name() (string, error) {
+	if p.peek() == '"' {
+		return p.quoted()
+	}
+	return p.ident()
+}
+
+func (p *textParser) integer() (int, error) {
+	p.skipSpace()
+	start := p.pos
+	if p.pos < len(p.text) && p.text[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.text) && p.text[p.pos] >= '0' && p.text[p.pos] <= '9' {
+		p.pos++
+	}
+	i, err := strconv.Atoi(p.text[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("expected an integer")
+	}
+	return i, nil
+}
+
+// value parses a scalar value: a quoted string, number, true, false, or null.
+func (p *textParser) value() (ipld.Node, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return p.newString(s), nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.text) && strings.IndexByte("+-.eE0123456789", p.text[p.pos]) >= 0 {
+			p.pos++
+		}
+		lit := p.text[start:p.pos]
+		if !strings.ContainsAny(lit, ".eE") {
+			i, err := strconv.Atoi(lit)
+			if err == nil {
+				return p.newInt(i), nil
+			}
+			if err.(*strconv.NumError).Err == strconv.ErrRange {
+				p.pos = start
+				return nil, p.errorf("integer %q out of range", lit)
+			}
+		} else if f, err := strconv.ParseFloat(lit, 64); err == nil {
+			return p.newFloat(f), nil
+		}
+		p.pos = start
+		return nil, p.errorf("invalid number %q", lit)
+	case isIdentStart(c):
+		start := p.pos
+		id, _ := p.ident()
+		switch id {
+		case "true":
+			return p.newBool(true), nil
+		case "false":
+			return p.newBool(false), nil
+		case "null":
+			nb := p.ns.NewBuilder()
+			if err := nb.AssignNull(); err != nil {
+				return nil, err
+			}
+			return nb.Build(), nil
+		}
+		p.pos = start
+		return nil, p.errorf("expected a value, not %q", id)
+	default:
+		return nil, p.errorf("expected a value")
+	}
+}
+
+// entry is one key and value for a map node under construction.
+type entry struct {
+	k string
+	v ipld.Node
+}
+
+// nodeMaker builds the nodes of a selector using a NodeStyle.
+// Its methods panic with fluent.Error on failure, as the fluent map and list
+// builders do; callers must recover them (e.g. using fluent.Recover).
+type nodeMaker struct {
+	ns ipld.NodeStyle
+}
+
+func (p nodeMaker) newString(s string) ipld.Node {
+	nb := p.ns.NewBuilder()
+	if err := nb.AssignString(s); err != nil {
+		panic(fluent.Error{Err: err})
+	}
+	return nb.Build()
+}
+
+func (p nodeMaker) newInt(i int) ipld.Node {
+	nb := p.ns.NewBuilder()
+	if err := nb.AssignInt(i); err != nil {
+		panic(fluent.Error{Err: err})
+	}
+	return nb.Build()
+}
+
+func (p nodeMaker) newFloat(f float64) ipld.Node {
+	nb := p.ns.NewBuilder()
+	if err := nb.AssignFloat(f); err != nil {
+		panic(fluent.Error{Err: err})
+	}
+	return nb.Build()
+}
+
+func (p nodeMaker) newBool(b bool) ipld.Node {
+	nb := p.ns.NewBuilder()
+	if err := nb.AssignBool(b); err != nil {
+		panic(fluent.Error{Err: err})
+	}
+	return nb.Build()
+}
+
+func (p nodeMaker) buildMap(entries ...entry) ipld.Node {
+	return fluent.MustBuildMap(p.ns, len(entries), func(na fluent.MapAssembler) {
+		for _, e := range entries {
+			na.AssembleEntry(e.k).AssignNode(e.v)
+		}
+	})
+}
+
+func (p nodeMaker) buildList(members []ipld.Node) ipld.Node {
+	return fluent.MustBuildList(p.ns, len(members), func(na fluent.ListAssembler) {
+		for _, m := range members {
+			na.AssembleValue().AssignNode(m)
+		}
+	})
+}
+
+func (p nodeMaker) emptyMap() ipld.Node {
+	return p.buildMap()
+}
+
+func (p *textParser) parseSelector() (ipld.Node, error) {
+	start := p.pos
+	id, err := p.ident()
+	if err != nil {
+		return nil, p.errorf("expected a selector")
+	}
+	switch id {
+	case "match":
+		var entries []entry
+		if p.accept('(') {
+			for !p.accept(')') {
+				if len(entries) > 0 {
+					if err := p.expect(','); err != nil {
+						return nil, err
+					}
+				}
+				switch {
+				case p.keyword("if"):
+					c, err := p.parseCondition()
+					if err != nil {
+						return nil, err
+					}
+					entries = append(entries, entry{SelectorKey_Condition, c})
+				case p.keyword("label"):
+					label, err := p.quoted()
+					if err != nil {
+						return nil, err
+					}
+					entries = append(entries, entry{SelectorKey_Label, p.newString(label)})
+				default:
+					return nil, p.errorf("expected \"if=\" or \"label=\" in match")
+				}
+			}
+		}
+		return p.buildMap(entry{SelectorKey_Matcher, p.buildMap(entries...)}), nil
+	case "edge":
+		return p.buildMap(entry{SelectorKey_ExploreRecursiveEdge, p.emptyMap()}), nil
+	case "all":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		next, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return p.buildMap(entry{SelectorKey_ExploreAll, p.buildMap(entry{SelectorKey_Next, next})}), nil
+	case "fields":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		var entries []entry
+		for !p.accept(')') {
+			if len(entries) > 0 {
+				if err := p.expect(','); err != nil {
+					return nil, err
+				}
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect('='); err != nil {
+				return nil, err
+			}
+			next, err := p.parseSelector()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{name, next})
+		}
+		return p.buildMap(entry{SelectorKey_ExploreFields, p.buildMap(entry{SelectorKey_Fields, p.buildMap(entries...)})}), nil
+	case "index":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		index, err := p.integer()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		next, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return p.buildMap(entry{SelectorKey_ExploreIndex, p.buildMap(
+			entry{SelectorKey_Index, p.newInt(index)},
+			entry{SelectorKey_Next, next},
+		)}), nil
+	case "range":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		start, err := p.integer()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		end, err := p.integer()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		next, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return p.buildMap(entry{SelectorKey_ExploreRange, p.buildMap(
+			entry{SelectorKey_Start, p.newInt(start)},
+			entry{SelectorKey_End, p.newInt(end)},
+			entry{SelectorKey_Next, next},
+		)}), nil
+	case "union":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		var members []ipld.Node
+		for !p.accept(')') {
+			if len(members) > 0 {
+				if err := p.expect(','); err != nil {
+					return nil, err
+				}
+			}
+			member, err := p.parseSelector()
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, member)
+		}
+		return p.buildMap(entry{SelectorKey_ExploreUnion, p.buildList(members)}), nil
+	case "recursive":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		var limit ipld.Node
+		switch {
+		case p.keyword(SelectorKey_LimitDepth):
+			depth, err := p.integer()
+			if err != nil {
+				return nil, err
+			}
+			limit = p.buildMap(entry{SelectorKey_LimitDepth, p.newInt(depth)})
+		default:
+			if id, err := p.ident(); err != nil || id != SelectorKey_LimitNone {
+				return nil, p.errorf("expected \"depth=\" or \"none\" as the limit in recursive")
+			}
+			limit = p.buildMap(entry{SelectorKey_LimitNone, p.emptyMap()})
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		sequence, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		entries := []entry{{SelectorKey_Limit, limit}, {SelectorKey_Sequence, sequence}}
+		if p.accept(',') {
+			if !p.keyword("stopAt") {
+				return nil, p.errorf("expected \"stopAt=\" in recursive")
+			}
+			stopAt, err := p.parseCondition()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{SelectorKey_StopAt, stopAt})
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return p.buildMap(entry{SelectorKey_ExploreRecursive, p.buildMap(entries...)}), nil
+	case "if":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		c, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		next, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return p.buildMap(entry{SelectorKey_ExploreConditional, p.buildMap(
+			entry{SelectorKey_Condition, c},
+			entry{SelectorKey_Next, next},
+		)}), nil
+	default:
+		p.pos = start
+		return nil, p.errorf("%q is not a known selector", id)
+	}
+}
+
+func (p *textParser) parseCondition() (ipld.Node, error) {
+	start := p.pos
+	id, err := p.ident()
+	if err != nil {
+		return nil, p.errorf("expected a condition")
+	}
+	switch id {
+	case "hasField":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		entries := []entry{{SelectorKey_FieldName, p.newString(name)}}
+		if p.accept(',') {
+			c, err := p.parseCondition()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{SelectorKey_Condition, c})
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return p.buildMap(entry{SelectorKey_ConditionHasField, p.buildMap(entries...)}), nil
+	case "eq", "gt", "lt":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		k := map[string]string{
+			"eq": SelectorKey_ConditionHasValue,
+			"gt": SelectorKey_ConditionGreaterThan,
+			"lt": SelectorKey_ConditionLessThan,
+		}[id]
+		return p.buildMap(entry{k, v}), nil
+	case "kind":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		kstr, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		k, ok := parseReprKind(kstr)
+		if !ok {
+			return nil, p.errorf("%q is not a known kind", kstr)
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return p.buildMap(entry{SelectorKey_ConditionHasKind, p.newString(k.String())}), nil
+	case "isLink":
+		return p.buildMap(entry{SelectorKey_ConditionIsLink, p.emptyMap()}), nil
+	case "and", "or":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		var members []ipld.Node
+		for !p.accept(')') {
+			if len(members) > 0 {
+				if err := p.expect(','); err != nil {
+					return nil, err
+				}
+			}
+			member, err := p.parseCondition()
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, member)
+		}
+		k := SelectorKey_ConditionAnd
+		if id == "or" {
+			k = SelectorKey_ConditionOr
+		}
+		return p.buildMap(entry{k, p.buildList(members)}), nil
+	default:
+		p.pos = start
+		return nil, p.errorf("%q is not a known condition", id)
+	}
+}
+
+// PrintText returns the text syntax for a selector Node.
+// (See ParseTextNode for the syntax.)
+//
+// An error is returned if the Node is not a well-formed selector,
+// or if it contains values which can't be written in the text syntax
+// (for example, a condition comparing against bytes or a link).
+func PrintText(n ipld.Node) (string, error) {
+	var sb strings.Builder
+	if err := printSelector(&sb, n); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// unionMember returns the single key and value of a keyed union node.
+func unionMember(n ipld.Node, what string) (string, ipld.Node, error) {
+	if n.ReprKind() != ipld.ReprKind_Map || n.Length() != 1 {
+		return "", nil, fmt.Errorf("selector text print rejected: %s is a keyed union and thus must be a single-entry map", what)
+	}
+	kn, v, err := n.MapIterator().Next()
+	if err != nil {
+		return "", nil, err
+	}
+	k, err := kn.AsString()
+	if err != nil {
+		return "", nil, err
+	}
+	return k, v, nil
+}
+
+// lookup returns a required field of a selector body.
+func lookup(n ipld.Node, k string, what string) (ipld.Node, error) {
+	if n.ReprKind() != ipld.ReprKind_Map {
+		return nil, fmt.Errorf("selector text print rejected: %s body must be a map", what)
+	}
+	v, err := n.LookupString(k)
+	if err != nil {
+		return nil, fmt.Errorf("selector text print rejected: %q field must be present in %s", k, what)
+	}
+	return v, nil
+}
+
+// lookupOptional returns an optional field of a selector body, or nil.
+func lookupOptional(n ipld.Node, k string) ipld.Node {
+	v, err := n.LookupString(k)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func printInt(sb *strings.Builder, n ipld.Node, what string) error {
+	i, err := n.AsInt()
+	if err != nil {
+		return fmt.Errorf("selector text print rejected: %s must be an int", what)
+	}
+	sb.WriteString(strconv.Itoa(i))
+	return nil
+}
+
+func printName(sb *strings.Builder, s string) {
+	ok := len(s) > 0 && isIdentStart(s[0])
+	for i := 1; ok && i < len(s); i++ {
+		ok = isIdentPart(s[i])
+	}
+	if ok {
+		sb.WriteString(s)
+	} else {
+		sb.WriteString(strconv.Quote(s))
+	}
+}
+
+func printValue(sb *strings.Builder, n ipld.Node) error {
+	switch n.ReprKind() {
+	case ipld.ReprKind_String:
+		s, _ := n.AsString()
+		sb.WriteString(strconv.Quote(s))
+	case ipld.ReprKind_Int:
+		i, _ := n.AsInt()
+		sb.WriteString(strconv.Itoa(i))
+	case ipld.ReprKind_Float:
+		f, _ := n.AsFloat()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("selector text print rejected: %v cannot be written in text syntax", f)
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		sb.WriteString(s)
+	case ipld.ReprKind_Bool:
+		b, _ := n.AsBool()
+		sb.WriteString(strconv.FormatBool(b))
+	case ipld.ReprKind_Null:
+		sb.WriteString("null")
+	default:
+		return fmt.Errorf("selector text print rejected: values of kind %s cannot be written in text syntax", n.ReprKind())
+	}
+	return nil
+}
+
+func printSelector(sb *strings.Builder, n ipld.Node) error {
+	k, v, err := unionMember(n, "selector")
+	if err != nil {
+		return err
+	}
+	switch k {
+	case SelectorKey_Matcher:
+		if v.ReprKind() != ipld.ReprKind_Map {
+			return fmt.Errorf("selector text print rejected: Matcher body must be a map")
+		}
+		sb.WriteString("match")
+		c := lookupOptional(v, SelectorKey_Condition)
+		label := lookupOptional(v, SelectorKey_Label)
+		if c == nil && label == nil {
+			return nil
+		}
+		sb.WriteString("(")
+		if c != nil {
+			sb.WriteString("if=")
+			if err := printCondition(sb, c); err != nil {
+				return err
+			}
+		}
+		if label != nil {
+			if c != nil {
+				sb.WriteString(", ")
+			}
+			s, err := label.AsString()
+			if err != nil {
+				return fmt.Errorf("selector text print rejected: label field must be a string in Matcher")
+			}
+			sb.WriteString("label=")
+			sb.WriteString(strconv.Quote(s))
+		}
+		sb.WriteString(")")
+	case SelectorKey_ExploreRecursiveEdge:
+		sb.WriteString("edge")
+	case SelectorKey_ExploreAll:
+		next, err := lookup(v, SelectorKey_Next, "ExploreAll")
+		if err != nil {
+			return err
+		}
+		sb.WriteString("all(")
+		if err := printSelector(sb, next); err != nil {
+			return err
+		}
+		sb.WriteString(")")
+	case SelectorKey_ExploreFields:
+		fields, err := lookup(v, SelectorKey_Fields, "ExploreFields")
+		if err != nil {
+			return err
+		}
+		if fields.ReprKind() != ipld.ReprKind_Map {
+			return fmt.Errorf("selector text print rejected: fields in ExploreFields must be a map")
+		}
+		sb.WriteString("fields(")
+		for itr, first := fields.MapIterator(), true; !itr.Done(); first = false {
+			kn, next, err := itr.Next()
+			if err != nil {
+				return err
+			}
+			if !first {
+				sb.WriteString(", ")
+			}
+			ks, _ := kn.AsString()
+			printName(sb, ks)
+			sb.WriteString("=")
+			if err := printSelector(sb, next); err != nil {
+				return err
+			}
+		}
+		sb.WriteString(")")
+	case SelectorKey_ExploreIndex:
+		index, err := lookup(v, SelectorKey_Index, "ExploreIndex")
+		if err != nil {
+			return err
+		}
+		next, err := lookup(v, SelectorKey_Next, "ExploreIndex")
+		if err != nil {
+			return err
+		}
+		sb.WriteString("index(")
+		if err := printInt(sb, index, "index in ExploreIndex"); err != nil {
+			return err
+		}
+		sb.WriteString(", ")
+		if err := printSelector(sb, next); err != nil {
+			return err
+		}
+		sb.WriteString(")")
+	case SelectorKey_ExploreRange:
+		start, err := lookup(v, SelectorKey_Start, "ExploreRange")
+		if err != nil {
+			return err
+		}
+		end, err := lookup(v, SelectorKey_End, "ExploreRange")
+		if err != nil {
+			return err
+		}
+		next, err := lookup(v, SelectorKey_Next, "ExploreRange")
+		if err != nil {
+			return err
+		}
+		sb.WriteString("range(")
+		if err := printInt(sb, start, "start in ExploreRange"); err != nil {
+			return err
+		}
+		sb.WriteString(", ")
+		if err := printInt(sb, end, "end in ExploreRange"); err != nil {
+			return err
+		}
+		sb.WriteString(", ")
+		if err := printSelector(sb, next); err != nil {
+			return err
+		}
+		sb.WriteString(")")
+	case SelectorKey_ExploreUnion:
+		if v.ReprKind() != ipld.ReprKind_List {
+			return fmt.Errorf("selector text print rejected: ExploreUnion must be a list")
+		}
+		sb.WriteString("union(")
+		for itr := v.ListIterator(); !itr.Done(); {
+			i, member, err := itr.Next()
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			if err := printSelector(sb, member); err != nil {
+				return err
+			}
+		}
+		sb.WriteString(")")
+	case SelectorKey_ExploreRecursive:
+		limit, err := lookup(v, SelectorKey_Limit, "ExploreRecursive")
+		if err != nil {
+			return err
+		}
+		sequence, err := lookup(v, SelectorKey_Sequence, "ExploreRecursive")
+		if err != nil {
+			return err
+		}
+		sb.WriteString("recursive(")
+		lk, lv, err := unionMember(limit, "limit in ExploreRecursive")
+		if err != nil {
+			return err
+		}
+		switch lk {
+		case SelectorKey_LimitDepth:
+			sb.WriteString(SelectorKey_LimitDepth + "=")
+			if err := printInt(sb, lv, "depth limit in ExploreRecursive"); err != nil {
+				return err
+			}
+		case SelectorKey_LimitNone:
+			sb.WriteString(SelectorKey_LimitNone)
+		default:
+			return fmt.Errorf("selector text print rejected: %q is not a known member of the limit union in ExploreRecursive", lk)
+		}
+		sb.WriteString(", ")
+		if err := printSelector(sb, sequence); err != nil {
+			return err
+		}
+		if stopAt := lookupOptional(v, SelectorKey_StopAt); stopAt != nil {
+			sb.WriteString(", stopAt=")
+			if err := printCondition(sb, stopAt); err != nil {
+				return err
+			}
+		}
+		sb.WriteString(")")
+	case SelectorKey_ExploreConditional:
+		c, err := lookup(v, SelectorKey_Condition, "ExploreConditional")
+		if err != nil {
+			return err
+		}
+		next, err := lookup(v, SelectorKey_Next, "ExploreConditional")
+		if err != nil {
+			return err
+		}
+		sb.WriteString("if(")
+		if err := printCondition(sb, c); err != nil {
+			return err
+		}
+		sb.WriteString(", ")
+		if err := printSelector(sb, next); err != nil {
+			return err
+		}
+		sb.WriteString(")")
+	default:
+		return fmt.Errorf("selector text print rejected: %q is not a known member of the selector union", k)
+	}
+	return nil
+}
+
+func printCondition(sb *strings.Builder, n ipld.Node) error {
+	k, v, err := unionMember(n, "condition")
+	if err != nil {
+		return err
+	}
+	switch k {
+	case SelectorKey_ConditionHasField:
+		nameNode, err := lookup(v, SelectorKey_FieldName, "hasField condition")
+		if err != nil {
+			return err
+		}
+		name, err := nameNode.AsString()
+		if err != nil {
+			return fmt.Errorf("selector text print rejected: name field must be a string in hasField condition")
+		}
+		sb.WriteString("hasField(")
+		printName(sb, name)
+		if sub := lookupOptional(v, SelectorKey_Condition); sub != nil {
+			sb.WriteString(", ")
+			if err := printCondition(sb, sub); err != nil {
+				return err
+			}
+		}
+		sb.WriteString(")")
+	case SelectorKey_ConditionHasValue, SelectorKey_ConditionGreaterThan, SelectorKey_ConditionLessThan:
+		sb.WriteString(map[string]string{
+			SelectorKey_ConditionHasValue:    "eq(",
+			SelectorKey_ConditionGreaterThan: "gt(",
+			SelectorKey_ConditionLessThan:    "lt(",
+		}[k])
+		if err := printValue(sb, v); err != nil {
+			return err
+		}
+		sb.WriteString(")")
+	case SelectorKey_ConditionHasKind:
+		kstr, err := v.AsString()
+		if err != nil {
+			return fmt.Errorf("selector text print rejected: kind in %q condition must be a string", k)
+		}
+		kind, ok := parseReprKind(kstr)
+		if !ok {
+			return fmt.Errorf("selector text print rejected: %q is not a known kind", kstr)
+		}
+		sb.WriteString("kind(")
+		sb.WriteString(strings.ToLower(kind.String()))
+		sb.WriteString(")")
+	case SelectorKey_ConditionIsLink:
+		sb.WriteString("isLink")
+	case SelectorKey_ConditionAnd, SelectorKey_ConditionOr:
+		if v.ReprKind() != ipld.ReprKind_List {
+			return fmt.Errorf("selector text print rejected: %q condition body must be a list", k)
+		}
+		sb.WriteString(k)
+		sb.WriteString("(")
+		for itr := v.ListIterator(); !itr.Done(); {
+			i, member, err := itr.Next()
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			if err := printCondition(sb, member); err != nil {
+				return err
+			}
+		}
+		sb.WriteString(")")
+	default:
+		return fmt.Errorf("selector text print rejected: %q is not a known member of the condition union", k)
+	}
+	return nil
+}
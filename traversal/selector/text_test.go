@@ -0,0 +1,111 @@
+package selector
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	"github.com/ipld/go-ipld-prime/fluent"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+// parseText is builder.ParseText, which can't be used from here.
+func parseText(text string) (Selector, error) {
+	n, err := ParseTextNode(text, basicnode.Style__Any{})
+	if err != nil {
+		return nil, err
+	}
+	return ParseSelector(n)
+}
+
+func TestParseText(t *testing.T) {
+	t.Run("parsing a matcher should work", func(t *testing.T) {
+		s, err := parseText("match")
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, s, ShouldEqual, Matcher{})
+	})
+	t.Run("parsing should produce the same node as the map form", func(t *testing.T) {
+		n, err := ParseTextNode(`recursive(depth=5, all(edge))`, basicnode.Style__Any{})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, n, ShouldEqual, fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(SelectorKey_ExploreRecursive).CreateMap(2, func(na fluent.MapAssembler) {
+				na.AssembleEntry(SelectorKey_Limit).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(SelectorKey_LimitDepth).AssignInt(5)
+				})
+				na.AssembleEntry(SelectorKey_Sequence).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(SelectorKey_ExploreAll).CreateMap(1, func(na fluent.MapAssembler) {
+						na.AssembleEntry(SelectorKey_Next).CreateMap(1, func(na fluent.MapAssembler) {
+							na.AssembleEntry(SelectorKey_ExploreRecursiveEdge).CreateMap(0, func(na fluent.MapAssembler) {})
+						})
+					})
+				})
+			})
+		}))
+	})
+	t.Run("parsing should ignore whitespace", func(t *testing.T) {
+		a, err := ParseTextNode("fields(\n\tfoo = index( 2 ,match ),\n\t\"b a r\"=match\n)", basicnode.Style__Any{})
+		Wish(t, err, ShouldEqual, nil)
+		b, err := ParseTextNode(`fields(foo=index(2,match),"b a r"=match)`, basicnode.Style__Any{})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, a, ShouldEqual, b)
+	})
+	t.Run("parsing unknown selectors should error", func(t *testing.T) {
+		_, err := parseText("all(nope)")
+		Wish(t, err, ShouldEqual, fmt.Errorf(`selector text parse rejected at offset 4: "nope" is not a known selector`))
+	})
+	t.Run("parsing incomplete text should error", func(t *testing.T) {
+		_, err := parseText("all(match")
+		Wish(t, err, ShouldEqual, fmt.Errorf(`selector text parse rejected at offset 9: expected ')'`))
+	})
+	t.Run("parsing trailing text should error", func(t *testing.T) {
+		_, err := parseText("match match")
+		Wish(t, err, ShouldEqual, fmt.Errorf(`selector text parse rejected at offset 6: unexpected trailing text`))
+	})
+	t.Run("parsing integers too large for an int should error", func(t *testing.T) {
+		_, err := parseText("match(if=eq(99999999999999999999))")
+		Wish(t, err, ShouldEqual, fmt.Errorf(`selector text parse rejected at offset 12: integer "99999999999999999999" out of range`))
+		_, err = parseText("match(if=eq(99999999999999999999.0))")
+		Wish(t, err, ShouldEqual, nil)
+	})
+	t.Run("parsing semantically invalid selectors should error", func(t *testing.T) {
+		_, err := parseText("recursive(none, all(match))")
+		Wish(t, err, ShouldEqual, fmt.Errorf("selector spec parse rejected: ExploreRecursive must have at least one ExploreRecursiveEdge"))
+	})
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	for _, text := range []string{
+		`match`,
+		`match(if=and(hasField(type, eq("dir")), gt(-1.5)), label="dirs")`,
+		`match(label="all")`,
+		`all(match)`,
+		`fields(foo=match, "b a r"=index(2, match), "3"=range(1, 4, match))`,
+		`union(match, all(match))`,
+		`recursive(depth=5, all(edge))`,
+		`recursive(none, union(match, fields(prev=edge)), stopAt=or(isLink, kind(bytes), lt("m"), eq(null)))`,
+		`if(hasField(type), fields(entries=all(match)))`,
+		`all(if(eq(true), match))`,
+	} {
+		t.Run(text, func(t *testing.T) {
+			n, err := ParseTextNode(text, basicnode.Style__Any{})
+			Require(t, err, ShouldEqual, nil)
+			_, err = ParseSelector(n)
+			Wish(t, err, ShouldEqual, nil)
+			printed, err := PrintText(n)
+			Wish(t, err, ShouldEqual, nil)
+			Wish(t, printed, ShouldEqual, text)
+		})
+	}
+	t.Run("printing values which have no text syntax should error", func(t *testing.T) {
+		n := fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(SelectorKey_Matcher).CreateMap(1, func(na fluent.MapAssembler) {
+				na.AssembleEntry(SelectorKey_Condition).CreateMap(1, func(na fluent.MapAssembler) {
+					na.AssembleEntry(SelectorKey_ConditionHasValue).AssignBytes([]byte("x"))
+				})
+			})
+		})
+		_, err := PrintText(n)
+		Wish(t, err, ShouldEqual, fmt.Errorf("selector text print rejected: values of kind Bytes cannot be written in text syntax"))
+	})
+}
@@ -0,0 +1,322 @@
+package selector
+
+import (
+	"fmt"
+
+	ipld "github.com/ipld/go-ipld-prime"
+)
+
+// ValidationError describes one problem found in a selector Node by Validate.
+type ValidationError struct {
+	// Path is where the problem is, within the selector Node.
+	Path ipld.Path
+
+	// Reason is a description of the problem.
+	Reason string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("selector spec invalid at %q: %s", e.Path, e.Reason)
+}
+
+// Validate checks an entire selector Node, and returns a ValidationError for
+// every problem found in it, or nil if there are none.
+//
+// Unlike ParseSelector, which stops at the first problem, Validate keeps going,
+// so the results describe everything wrong with a selector at once.
+// Validate also checks some semantic constraints which aren't a matter of
+// structure -- for example, that every ExploreRecursive contains an
+// ExploreRecursiveEdge, and that the start of an ExploreRange is before its end --
+// and it is strict about unknown fields, which ParseSelector ignores.
+// This makes it appropriate for checking selectors from untrusted sources
+// before using them.
+//
+// A selector which passes Validate will also be accepted by ParseSelector.
+func Validate(n ipld.Node) []error {
+	v := &validator{}
+	v.selector(ipld.Path{}, n)
+	return v.errs
+}
+
+type validator struct {
+	errs  []error
+	edges []int // one counter of ExploreRecursiveEdge found per enclosing ExploreRecursive (innermost last)
+}
+
+func (v *validator) errorf(p ipld.Path, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{p, fmt.Sprintf(format, args...)})
+}
+
+// unionMember returns the single key and value of a keyed union node,
+// or false (having noted the problem) if it isn't one.
+func (v *validator) unionMember(p ipld.Path, n ipld.Node, what string) (string, ipld.Node, bool) {
+	if n.ReprKind() != ipld.ReprKind_Map {
+		v.errorf(p, "%s is a keyed union and thus must be a map", what)
+		return "", nil, false
+	}
+	if n.Length() != 1 {
+		v.errorf(p, "%s is a keyed union and thus must be a single-entry map", what)
+		return "", nil, false
+	}
+	kn, val, err := n.MapIterator().Next()
+	if err != nil {
+		v.errorf(p, "%s", err)
+		return "", nil, false
+	}
+	k, err := kn.AsString()
+	if err != nil {
+		v.errorf(p, "%s keys must be strings", what)
+		return "", nil, false
+	}
+	return k, val, true
+}
+
+// body checks that a selector or condition body is a map with only the
+// given known fields, and that the required ones are present;
+// it returns false (having noted the problems) if the body isn't a map at all.
+func (v *validator) body(p ipld.Path, n ipld.Node, what string, required []string, optional ...string) bool {
+	if n.ReprKind() != ipld.ReprKind_Map {
+		v.errorf(p, "%s body must be a map", what)
+		return false
+	}
+	for _, k := range required {
+		if _, err := n.LookupString(k); err != nil {
+			v.errorf(p, "%q field must be present in %s", k, what)
+		}
+	}
+	for itr := n.MapIterator(); !itr.Done(); {
+		kn, _, err := itr.Next()
+		if err != nil {
+			v.errorf(p, "%s", err)
+			return true
+		}
+		k, _ := kn.AsString()
+		if !contains(required, k) && !contains(optional, k) {
+			v.errorf(p.AppendSegmentString(k), "%q is not a known field in %s", k, what)
+		}
+	}
+	return true
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// field returns a field of a map, or nil if it's absent.
+func field(n ipld.Node, k string) ipld.Node {
+	val, err := n.LookupString(k)
+	if err != nil {
+		return nil
+	}
+	return val
+}
+
+// nonNegativeInt checks that a field (if present) is an int of at least zero,
+// returning it and whether it's usable.
+func (v *validator) nonNegativeInt(p ipld.Path, n ipld.Node, what string) (int, bool) {
+	if n == nil {
+		return 0, false
+	}
+	i, err := n.AsInt()
+	if err != nil {
+		v.errorf(p, "%s must be an int", what)
+		return 0, false
+	}
+	if i < 0 {
+		v.errorf(p, "%s must not be negative", what)
+		return i, false
+	}
+	return i, true
+}
+
+func (v *validator) next(p ipld.Path, n ipld.Node, k string) {
+	if next := field(n, k); next != nil {
+		v.selector(p.AppendSegmentString(k), next)
+	}
+}
+
+func (v *validator) selector(p ipld.Path, n ipld.Node) {
+	k, body, ok := v.unionMember(p, n, "selector")
+	if !ok {
+		return
+	}
+	p = p.AppendSegmentString(k)
+	switch k {
+	case SelectorKey_Matcher:
+		if !v.body(p, body, "Matcher", nil, SelectorKey_Condition, SelectorKey_Label) {
+			return
+		}
+		if c := field(body, SelectorKey_Condition); c != nil {
+			v.condition(p.AppendSegmentString(SelectorKey_Condition), c)
+		}
+		if label := field(body, SelectorKey_Label); label != nil && label.ReprKind() != ipld.ReprKind_String {
+			v.errorf(p.AppendSegmentString(SelectorKey_Label), "label must be a string")
+		}
+	case SelectorKey_ExploreAll:
+		if !v.body(p, body, "ExploreAll", []string{SelectorKey_Next}) {
+			return
+		}
+		v.next(p, body, SelectorKey_Next)
+	case SelectorKey_ExploreFields:
+		if !v.body(p, body, "ExploreFields", []string{SelectorKey_Fields}) {
+			return
+		}
+		fields := field(body, SelectorKey_Fields)
+		if fields == nil {
+			return
+		}
+		fp := p.AppendSegmentString(SelectorKey_Fields)
+		if fields.ReprKind() != ipld.ReprKind_Map {
+			v.errorf(fp, "fields in ExploreFields must be a map")
+			return
+		}
+		for itr := fields.MapIterator(); !itr.Done(); {
+			kn, next, err := itr.Next()
+			if err != nil {
+				v.errorf(fp, "%s", err)
+				return
+			}
+			ks, _ := kn.AsString()
+			v.selector(fp.AppendSegmentString(ks), next)
+		}
+	case SelectorKey_ExploreIndex:
+		if !v.body(p, body, "ExploreIndex", []string{SelectorKey_Index, SelectorKey_Next}) {
+			return
+		}
+		v.nonNegativeInt(p.AppendSegmentString(SelectorKey_Index), field(body, SelectorKey_Index), "index in ExploreIndex")
+		v.next(p, body, SelectorKey_Next)
+	case SelectorKey_ExploreRange:
+		if !v.body(p, body, "ExploreRange", []string{SelectorKey_Start, SelectorKey_End, SelectorKey_Next}) {
+			return
+		}
+		start, startOk := v.nonNegativeInt(p.AppendSegmentString(SelectorKey_Start), field(body, SelectorKey_Start), "start in ExploreRange")
+		end, endOk := v.nonNegativeInt(p.AppendSegmentString(SelectorKey_End), field(body, SelectorKey_End), "end in ExploreRange")
+		if startOk && endOk && start >= end {
+			v.errorf(p, "end (%d) must be greater than start (%d) in ExploreRange", end, start)
+		}
+		v.next(p, body, SelectorKey_Next)
+	case SelectorKey_ExploreUnion:
+		if body.ReprKind() != ipld.ReprKind_List {
+			v.errorf(p, "ExploreUnion must be a list")
+			return
+		}
+		if body.Length() == 0 {
+			v.errorf(p, "ExploreUnion must have at least one member")
+		}
+		for itr := body.ListIterator(); !itr.Done(); {
+			i, member, err := itr.Next()
+			if err != nil {
+				v.errorf(p, "%s", err)
+				return
+			}
+			v.selector(p.AppendSegment(ipld.PathSegmentOfInt(i)), member)
+		}
+	case SelectorKey_ExploreRecursive:
+		if !v.body(p, body, "ExploreRecursive", []string{SelectorKey_Limit, SelectorKey_Sequence}, SelectorKey_StopAt) {
+			return
+		}
+		if limit := field(body, SelectorKey_Limit); limit != nil {
+			lp := p.AppendSegmentString(SelectorKey_Limit)
+			if lk, lv, ok := v.unionMember(lp, limit, "limit in ExploreRecursive"); ok {
+				switch lk {
+				case SelectorKey_LimitDepth:
+					v.nonNegativeInt(lp.AppendSegmentString(lk), lv, "depth limit in ExploreRecursive")
+				case SelectorKey_LimitNone:
+					v.body(lp.AppendSegmentString(lk), lv, "none limit in ExploreRecursive", nil)
+				default:
+					v.errorf(lp, "%q is not a known member of the limit union in ExploreRecursive", lk)
+				}
+			}
+		}
+		if sequence := field(body, SelectorKey_Sequence); sequence != nil {
+			v.edges = append(v.edges, 0)
+			v.selector(p.AppendSegmentString(SelectorKey_Sequence), sequence)
+			if v.edges[len(v.edges)-1] == 0 {
+				v.errorf(p, "ExploreRecursive must have at least one ExploreRecursiveEdge in its sequence")
+			}
+			v.edges = v.edges[:len(v.edges)-1]
+		}
+		if stopAt := field(body, SelectorKey_StopAt); stopAt != nil {
+			v.condition(p.AppendSegmentString(SelectorKey_StopAt), stopAt)
+		}
+	case SelectorKey_ExploreRecursiveEdge:
+		v.body(p, body, "ExploreRecursiveEdge", nil)
+		if len(v.edges) == 0 {
+			v.errorf(p, "ExploreRecursiveEdge must be beneath ExploreRecursive")
+			return
+		}
+		v.edges[len(v.edges)-1]++
+	case SelectorKey_ExploreConditional:
+		if !v.body(p, body, "ExploreConditional", []string{SelectorKey_Condition, SelectorKey_Next}) {
+			return
+		}
+		if c := field(body, SelectorKey_Condition); c != nil {
+			v.condition(p.AppendSegmentString(SelectorKey_Condition), c)
+		}
+		v.next(p, body, SelectorKey_Next)
+	default:
+		v.errorf(p.Parent(), "%q is not a known member of the selector union", k)
+	}
+}
+
+func (v *validator) condition(p ipld.Path, n ipld.Node) {
+	k, body, ok := v.unionMember(p, n, "condition")
+	if !ok {
+		return
+	}
+	p = p.AppendSegmentString(k)
+	switch k {
+	case SelectorKey_ConditionHasField:
+		if !v.body(p, body, "hasField condition", []string{SelectorKey_FieldName}, SelectorKey_Condition) {
+			return
+		}
+		if name := field(body, SelectorKey_FieldName); name != nil && name.ReprKind() != ipld.ReprKind_String {
+			v.errorf(p.AppendSegmentString(SelectorKey_FieldName), "name must be a string in hasField condition")
+		}
+		if sub := field(body, SelectorKey_Condition); sub != nil {
+			v.condition(p.AppendSegmentString(SelectorKey_Condition), sub)
+		}
+	case SelectorKey_ConditionHasValue:
+		switch body.ReprKind() {
+		case ipld.ReprKind_Map, ipld.ReprKind_List:
+			v.errorf(p, "value in %q condition must be a scalar", k)
+		}
+	case SelectorKey_ConditionGreaterThan, SelectorKey_ConditionLessThan:
+		switch body.ReprKind() {
+		case ipld.ReprKind_Int, ipld.ReprKind_Float, ipld.ReprKind_String:
+		default:
+			v.errorf(p, "value in %q condition must be a number or string", k)
+		}
+	case SelectorKey_ConditionHasKind:
+		kstr, err := body.AsString()
+		if err != nil {
+			v.errorf(p, "kind in %q condition must be a string", k)
+			return
+		}
+		if _, ok := parseReprKind(kstr); !ok {
+			v.errorf(p, "%q is not a known kind", kstr)
+		}
+	case SelectorKey_ConditionIsLink:
+		v.body(p, body, "isLink condition", nil)
+	case SelectorKey_ConditionAnd, SelectorKey_ConditionOr:
+		if body.ReprKind() != ipld.ReprKind_List {
+			v.errorf(p, "%q condition body must be a list", k)
+			return
+		}
+		for itr := body.ListIterator(); !itr.Done(); {
+			i, member, err := itr.Next()
+			if err != nil {
+				v.errorf(p, "%s", err)
+				return
+			}
+			v.condition(p.AppendSegment(ipld.PathSegmentOfInt(i)), member)
+		}
+	default:
+		v.errorf(p.Parent(), "%q is not a known member of the condition union", k)
+	}
+}
@@ -0,0 +1,93 @@
+package selector
+
+import (
+	"testing"
+
+	. "github.com/warpfork/go-wish"
+
+	ipld "github.com/ipld/go-ipld-prime"
+	"github.com/ipld/go-ipld-prime/fluent"
+	basicnode "github.com/ipld/go-ipld-prime/node/basic"
+)
+
+func TestValidate(t *testing.T) {
+	mustText := func(text string) ipld.Node {
+		n, err := ParseTextNode(text, basicnode.Style__Any{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	errorStrings := func(errs []error) []string {
+		var ss []string
+		for _, err := range errs {
+			ss = append(ss, err.Error())
+		}
+		return ss
+	}
+	t.Run("valid selectors should have no problems", func(t *testing.T) {
+		for _, text := range []string{
+			`match`,
+			`fields(a=match(if=hasField("x", eq(1)), label="l"), b=index(2, all(match)))`,
+			`recursive(depth=3, union(match, all(edge)), stopAt=isLink)`,
+			`range(1, 4, if(kind(string), match))`,
+		} {
+			sn := mustText(text)
+			Wish(t, Validate(sn), ShouldEqual, []error(nil))
+			_, err := ParseContext{}.ParseSelector(sn)
+			Wish(t, err, ShouldEqual, nil)
+		}
+	})
+	t.Run("semantic problems should all be reported with their paths", func(t *testing.T) {
+		sn := mustText(`union(range(4, 2, match), recursive(depth=2, all(match)), fields(x=edge))`)
+		Wish(t, errorStrings(Validate(sn)), ShouldEqual, []string{
+			`selector spec invalid at "|/0/r": end (2) must be greater than start (4) in ExploreRange`,
+			`selector spec invalid at "|/1/R": ExploreRecursive must have at least one ExploreRecursiveEdge in its sequence`,
+			`selector spec invalid at "|/2/f/f>/x/@": ExploreRecursiveEdge must be beneath ExploreRecursive`,
+		})
+	})
+	t.Run("edges only count toward their innermost recursive", func(t *testing.T) {
+		sn := mustText(`recursive(depth=1, fields(a=recursive(depth=1, edge)))`)
+		Wish(t, Validate(sn), ShouldEqual, []error{
+			ValidationError{ipld.ParsePath("R"), "ExploreRecursive must have at least one ExploreRecursiveEdge in its sequence"},
+		})
+	})
+	t.Run("structural problems should all be reported with their paths", func(t *testing.T) {
+		sn := fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry(SelectorKey_ExploreFields).CreateMap(2, func(na fluent.MapAssembler) {
+				na.AssembleEntry(SelectorKey_Fields).CreateMap(3, func(na fluent.MapAssembler) {
+					na.AssembleEntry("a").CreateMap(1, func(na fluent.MapAssembler) {
+						na.AssembleEntry("bogus").CreateMap(0, func(na fluent.MapAssembler) {})
+					})
+					na.AssembleEntry("b").CreateMap(1, func(na fluent.MapAssembler) {
+						na.AssembleEntry(SelectorKey_ExploreIndex).CreateMap(1, func(na fluent.MapAssembler) {
+							na.AssembleEntry(SelectorKey_Index).AssignInt(-1)
+						})
+					})
+					na.AssembleEntry("c").CreateMap(1, func(na fluent.MapAssembler) {
+						na.AssembleEntry(SelectorKey_Matcher).CreateMap(1, func(na fluent.MapAssembler) {
+							na.AssembleEntry(SelectorKey_Condition).CreateMap(1, func(na fluent.MapAssembler) {
+								na.AssembleEntry(SelectorKey_ConditionHasKind).AssignString("widget")
+							})
+						})
+					})
+				})
+				na.AssembleEntry("extra").AssignBool(true)
+			})
+		})
+		Wish(t, Validate(sn), ShouldEqual, []error{
+			ValidationError{ipld.ParsePath("f/extra"), `"extra" is not a known field in ExploreFields`},
+			ValidationError{ipld.ParsePath("f/f>/a"), `"bogus" is not a known member of the selector union`},
+			ValidationError{ipld.ParsePath("f/f>/b/i"), `">" field must be present in ExploreIndex`},
+			ValidationError{ipld.ParsePath("f/f>/b/i/i"), "index in ExploreIndex must not be negative"},
+			ValidationError{ipld.ParsePath("f/f>/c/./&/%"), `"widget" is not a known kind`},
+		})
+	})
+	t.Run("non-map selector should be reported at the root", func(t *testing.T) {
+		errs := Validate(basicnode.NewInt(0))
+		Wish(t, errs, ShouldEqual, []error{
+			ValidationError{ipld.Path{}, "selector is a keyed union and thus must be a map"},
+		})
+		Wish(t, errs[0].Error(), ShouldEqual, `selector spec invalid at "": selector is a keyed union and thus must be a map`)
+	})
+}
@@ -2,6 +2,8 @@ package traversal
 
 import (
 	"fmt"
+	"reflect"
+	"sync"
 
 	ipld "github.com/ipld/go-ipld-prime"
 	"github.com/ipld/go-ipld-prime/traversal/selector"
@@ -57,8 +59,8 @@ func WalkTransforming(n ipld.Node, s selector.Selector, fn TransformFn) (ipld.No
 // This is important to note because when walking DAGs with Links,
 // it means you may visit the same node multiple times
 // due to having reached it via a different path.
-// (You can prevent this by using a LinkLoader function which memoizes a set of
-// already-visited Links, and returns a SkipMe when encountering them again.)
+// (You can prevent this by setting Config.LinkVisitOnce, which skips any
+// link that has already been visited with the same selector.)
 //
 // WalkMatching (and the other traversal functions) can be used again again inside the VisitFn!
 // By using the traversal.Progress handed to the VisitFn,
@@ -67,7 +69,7 @@ func WalkTransforming(n ipld.Node, s selector.Selector, fn TransformFn) (ipld.No
 //
 func (prog Progress) WalkMatching(n ipld.Node, s selector.Selector, fn VisitFn) error {
 	prog.init()
-	return prog.walkAdv(n, s, func(prog Progress, n ipld.Node, tr VisitReason) error {
+	return prog.walkAdv_ordered(n, s, func(prog Progress, n ipld.Node, tr VisitReason) error {
 		if tr != VisitReason_SelectionMatch {
 			return nil
 		}
@@ -81,12 +83,33 @@ func (prog Progress) WalkMatching(n ipld.Node, s selector.Selector, fn VisitFn)
 //
 func (prog Progress) WalkAdv(n ipld.Node, s selector.Selector, fn AdvVisitFn) error {
 	prog.init()
+	return prog.walkAdv_ordered(n, s, fn)
+}
+
+// walkAdv_ordered is walkAdv, or walkAdv_breadthFirst, as the Config.Order requires.
+func (prog Progress) walkAdv_ordered(n ipld.Node, s selector.Selector, fn AdvVisitFn) error {
+	if prog.Cfg.Order == TraversalOrder_BreadthFirst {
+		return prog.walkAdv_breadthFirst(n, s, fn)
+	}
 	return prog.walkAdv(n, s, fn)
 }
 
 func (prog Progress) walkAdv(n ipld.Node, s selector.Selector, fn AdvVisitFn) error {
+	if err := prog.walkAdv_visit(n, s, fn); err != nil {
+		return err
+	}
+	return prog.walkAdv_iterate(n, s, nil, fn)
+}
+
+// walkAdv_visit calls fn for a node (and not its children).
+func (prog Progress) walkAdv_visit(n ipld.Node, s selector.Selector, fn AdvVisitFn) error {
+	if err := prog.spendNode(); err != nil {
+		return err
+	}
 	if s.Decide(n) {
-		if err := fn(prog, n, VisitReason_SelectionMatch); err != nil {
+		progMatch := prog
+		progMatch.Labels = selector.Labels(s, n)
+		if err := fn(progMatch, n, VisitReason_SelectionMatch); err != nil {
 			return err
 		}
 	} else {
@@ -94,84 +117,348 @@ func (prog Progress) walkAdv(n ipld.Node, s selector.Selector, fn AdvVisitFn) er
 			return err
 		}
 	}
+	return nil
+}
+
+// walkAdv_iterate walks the children of n that the selector explores.
+//
+// If resume is non-nil, the walk is being resumed from a cursor (see ResumeWalkAdv),
+// and resume holds the segments of the cursor's path beneath n:
+// children before resume[0] are skipped entirely (they were walked before the cursor
+// was taken), the child at resume[0] is resumed with the rest of the segments,
+// and the children after it are walked normally.
+func (prog Progress) walkAdv_iterate(n ipld.Node, s selector.Selector, resume []string, fn AdvVisitFn) error {
 	nk := n.ReprKind()
 	switch nk {
 	case ipld.ReprKind_Map, ipld.ReprKind_List: // continue
 	default:
+		if resume != nil {
+			return prog.errCursorNotFound(resume)
+		}
 		return nil
 	}
 	attn := s.Interests()
 	if attn == nil {
-		return prog.walkAdv_iterateAll(n, s, fn)
+		return prog.walkAdv_iterateAll(n, s, resume, fn)
+	}
+	return prog.walkAdv_iterateSelective(n, attn, s, resume, fn)
+}
+
+func (prog Progress) walkAdv_iterateAll(n ipld.Node, s selector.Selector, resume []string, fn AdvVisitFn) error {
+	if prog.prefetch != nil {
+		return prog.walkAdv_iterateAllPrefetching(n, s, resume, fn)
+	}
+	for itr := selector.NewSegmentIterator(n); !itr.Done(); {
+		ps, v, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		if resume != nil && ps.String() != resume[0] {
+			continue
+		}
+		sNext := s.Explore(n, ps)
+		if sNext == nil {
+			if resume != nil {
+				return prog.errCursorNotFound(resume)
+			}
+			continue
+		}
+		if err := prog.walkAdv_child(n, ps, v, sNext, resume, fn); err != nil {
+			return err
+		}
+		resume = nil
 	}
-	return prog.walkAdv_iterateSelective(n, attn, s, fn)
+	if resume != nil {
+		return prog.errCursorNotFound(resume)
+	}
+	return nil
+}
 
+// walkAdv_child walks one child of n (loading it first, if it's a link).
+// If resume is non-nil, the child is resumed with resume[1:] (see walkAdv_iterate).
+func (prog Progress) walkAdv_child(n ipld.Node, ps ipld.PathSegment, v ipld.Node, sNext selector.Selector, resume []string, fn AdvVisitFn) error {
+	progNext := prog
+	progNext.Path = prog.Path.AppendSegment(ps)
+	if v.ReprKind() == ipld.ReprKind_Link {
+		lnk, _ := v.AsLink()
+		if !prog.firstLinkVisit(lnk, sNext) {
+			return nil
+		}
+		progNext.LastBlock.Path = progNext.Path
+		progNext.LastBlock.Link = lnk
+		var err error
+		v, err = progNext.loadLink(v, n)
+		if err != nil {
+			if _, ok := err.(SkipMe); ok {
+				return nil
+			}
+			return err
+		}
+	}
+	if resume != nil {
+		return progNext.walkAdv_resume(v, sNext, resume[1:], fn)
+	}
+	return progNext.walkAdv(v, sNext, fn)
 }
 
-func (prog Progress) walkAdv_iterateAll(n ipld.Node, s selector.Selector, fn AdvVisitFn) error {
+// prefetchedChild is a child of a node being walked with its selector,
+// and, if it's a link, the result of loading it (available once done is closed).
+type prefetchedChild struct {
+	prog   Progress
+	v      ipld.Node
+	sNext  selector.Selector
+	resume []string      // non-nil if this child is to be resumed (see walkAdv_iterate).
+	lnk    ipld.Link     // nil if v is not a link.
+	done   chan struct{} // nil if v is not a link.
+	loaded ipld.Node
+	err    error
+}
+
+// prefetchSlots limits the links being loaded concurrently in a traversal
+// (see Config.LinkPrefetchConcurrency): a load holds a slot until it's done.
+type prefetchSlots chan struct{}
+
+// walkAdv_iterateAllPrefetching is walkAdv_iterateAll, but loading links
+// concurrently: the links among the children of n are loaded in the background,
+// ahead of the child being visited, as long as the traversal has prefetch slots free.
+// Children are still visited (and fn called) in the same order as otherwise.
+//
+// If the walk stops early (because of an error), loads already in flight are
+// waited for (and their results discarded) before returning, so the LinkLoader
+// is never still running after the walk has returned.
+func (prog Progress) walkAdv_iterateAllPrefetching(n ipld.Node, s selector.Selector, resume []string, fn AdvVisitFn) error {
+	var children []*prefetchedChild
 	for itr := selector.NewSegmentIterator(n); !itr.Done(); {
 		ps, v, err := itr.Next()
 		if err != nil {
 			return err
 		}
+		if resume != nil && ps.String() != resume[0] {
+			continue
+		}
 		sNext := s.Explore(n, ps)
-		if sNext != nil {
-			progNext := prog
-			progNext.Path = prog.Path.AppendSegment(ps)
-			if v.ReprKind() == ipld.ReprKind_Link {
-				lnk, _ := v.AsLink()
-				progNext.LastBlock.Path = progNext.Path
-				progNext.LastBlock.Link = lnk
-				v, err = progNext.loadLink(v, n)
-				if err != nil {
-					if _, ok := err.(SkipMe); ok {
-						return nil
-					}
-					return err
+		if sNext == nil {
+			if resume != nil {
+				return prog.errCursorNotFound(resume)
+			}
+			continue
+		}
+		c := &prefetchedChild{prog: prog, v: v, sNext: sNext, resume: resume}
+		resume = nil
+		c.prog.Path = prog.Path.AppendSegment(ps)
+		if v.ReprKind() == ipld.ReprKind_Link {
+			lnk, _ := v.AsLink()
+			if prog.linkVisited(lnk, sNext) {
+				continue // don't bother loading it; firstLinkVisit will also say so when we reach it.
+			}
+			c.lnk = lnk
+			c.prog.LastBlock.Path = c.prog.Path
+			c.prog.LastBlock.Link = lnk
+			c.done = make(chan struct{})
+		}
+		children = append(children, c)
+	}
+	if resume != nil {
+		return prog.errCursorNotFound(resume)
+	}
+	next := 0 // children before this have had their loads started.
+	defer func() {
+		for _, f := range children[:next] {
+			if f.done != nil {
+				<-f.done
+			}
+		}
+	}()
+	for i, c := range children {
+		// Start more loads.  c's own load must be started, even if that means
+		//  waiting for a slot (which the loads holding them will give up when done);
+		//  loads of the children after it are only started while slots are free.
+		for ; next < len(children); next++ {
+			f := children[next]
+			if f.done == nil {
+				continue
+			}
+			if next <= i {
+				prog.prefetch <- struct{}{}
+			} else if !prog.prefetch.tryAcquire() {
+				break
+			}
+			go func() {
+				f.loaded, f.err = f.prog.loadLink(f.v, n)
+				<-prog.prefetch
+				close(f.done)
+			}()
+		}
+		v := c.v
+		if c.done != nil {
+			<-c.done
+			if !prog.firstLinkVisit(c.lnk, c.sNext) {
+				continue // an earlier sibling's walk visited it, after we started loading it.
+			}
+			if c.err != nil {
+				if _, ok := c.err.(SkipMe); ok {
+					continue
 				}
+				return c.err
 			}
-
-			err = progNext.walkAdv(v, sNext, fn)
-			if err != nil {
+			v = c.loaded
+		}
+		if c.resume != nil {
+			if err := c.prog.walkAdv_resume(v, c.sNext, c.resume[1:], fn); err != nil {
 				return err
 			}
+			continue
+		}
+		if err := c.prog.walkAdv(v, c.sNext, fn); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func (prog Progress) walkAdv_iterateSelective(n ipld.Node, attn []ipld.PathSegment, s selector.Selector, fn AdvVisitFn) error {
+// tryAcquire takes a slot if one is free, without waiting.
+func (slots prefetchSlots) tryAcquire() bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (prog Progress) walkAdv_iterateSelective(n ipld.Node, attn []ipld.PathSegment, s selector.Selector, resume []string, fn AdvVisitFn) error {
 	for _, ps := range attn {
+		if resume != nil && ps.String() != resume[0] {
+			continue
+		}
 		v, err := n.LookupSegment(ps)
 		if err != nil {
+			if resume != nil {
+				return prog.errCursorNotFound(resume)
+			}
 			continue
 		}
 		sNext := s.Explore(n, ps)
-		if sNext != nil {
-			progNext := prog
-			progNext.Path = prog.Path.AppendSegment(ps)
-			if v.ReprKind() == ipld.ReprKind_Link {
-				lnk, _ := v.AsLink()
-				progNext.LastBlock.Path = progNext.Path
-				progNext.LastBlock.Link = lnk
-				v, err = progNext.loadLink(v, n)
-				if err != nil {
-					if _, ok := err.(SkipMe); ok {
-						return nil
-					}
-					return err
-				}
+		if sNext == nil {
+			if resume != nil {
+				return prog.errCursorNotFound(resume)
 			}
+			continue
+		}
+		if err := prog.walkAdv_child(n, ps, v, sNext, resume, fn); err != nil {
+			return err
+		}
+		resume = nil
+	}
+	if resume != nil {
+		return prog.errCursorNotFound(resume)
+	}
+	return nil
+}
 
-			err = progNext.walkAdv(v, sNext, fn)
+// walkAdv_breadthFirst is walkAdv, but visiting nodes in breadth-first order:
+// every node at one depth is visited before any at the next depth.
+// (Depth here counts path segments, so a node beneath a link is one deeper
+// than the node containing the link, as usual.)
+// Siblings are visited in the same order as in the depth-first walk,
+// and links are loaded as they're reached in the breadth-first order.
+func (prog Progress) walkAdv_breadthFirst(n ipld.Node, s selector.Selector, fn AdvVisitFn) error {
+	type pending struct {
+		prog   Progress
+		parent ipld.Node // nil for the starting node.
+		v      ipld.Node
+		s      selector.Selector
+	}
+	queue := []pending{{prog, nil, n, s}}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue[0] = pending{} // release it for the GC.
+		queue = queue[1:]
+		n := p.v
+		if p.parent != nil && n.ReprKind() == ipld.ReprKind_Link {
+			lnk, _ := n.AsLink()
+			if !p.prog.firstLinkVisit(lnk, p.s) {
+				continue
+			}
+			p.prog.LastBlock.Path = p.prog.Path
+			p.prog.LastBlock.Link = lnk
+			var err error
+			n, err = p.prog.loadLink(n, p.parent)
 			if err != nil {
+				if _, ok := err.(SkipMe); ok {
+					continue
+				}
 				return err
 			}
 		}
+		if err := p.prog.walkAdv_visit(n, p.s, fn); err != nil {
+			return err
+		}
+		switch n.ReprKind() {
+		case ipld.ReprKind_Map, ipld.ReprKind_List: // continue
+		default:
+			continue
+		}
+		enqueue := func(ps ipld.PathSegment, v ipld.Node) {
+			sNext := p.s.Explore(n, ps)
+			if sNext == nil {
+				return
+			}
+			progNext := p.prog
+			progNext.Path = p.prog.Path.AppendSegment(ps)
+			queue = append(queue, pending{progNext, n, v, sNext})
+		}
+		if attn := p.s.Interests(); attn == nil {
+			for itr := selector.NewSegmentIterator(n); !itr.Done(); {
+				ps, v, err := itr.Next()
+				if err != nil {
+					return err
+				}
+				enqueue(ps, v)
+			}
+		} else {
+			for _, ps := range attn {
+				v, err := n.LookupSegment(ps)
+				if err != nil {
+					continue
+				}
+				enqueue(ps, v)
+			}
+		}
 	}
 	return nil
 }
 
+// LoadRoot loads the node a walk starts from, given its link, so that a walk
+// can begin at a link rather than a node.  The root is counted against the
+// budgets in the Config like any other link, and LastBlock is set to it;
+// so, to share those with the walk, use the same Progress for both:
+//
+//	n, err := prog.LoadRoot(lnk)
+//	...
+//	err = prog.WalkAdv(n, s, fn)
+func (prog *Progress) LoadRoot(root ipld.Link) (ipld.Node, error) {
+	prog.init()
+	lnkCtx := ipld.LinkContext{LinkPath: prog.Path}
+	ns, err := prog.Cfg.LinkTargetNodeStyleChooser(root, lnkCtx)
+	if err != nil {
+		return nil, fmt.Errorf("error traversing node at %q: could not load link %q: %s", prog.Path, root, err)
+	}
+	nb := ns.NewBuilder()
+	if err := prog.spendLink(); err != nil {
+		return nil, err
+	}
+	if err := root.Load(prog.Cfg.Ctx, lnkCtx, nb, prog.budgetedLoader()); err != nil {
+		if err := prog.bytesExceeded(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error traversing node at %q: could not load link %q: %s", prog.Path, root, err)
+	}
+	prog.LastBlock.Path = prog.Path
+	prog.LastBlock.Link = root
+	return nb.Build(), nil
+}
+
 func (prog Progress) loadLink(v ipld.Node, parent ipld.Node) (ipld.Node, error) {
 	lnk, err := v.AsLink()
 	if err != nil {
@@ -190,21 +477,75 @@ func (prog Progress) loadLink(v ipld.Node, parent ipld.Node) (ipld.Node, error)
 	}
 	nb := ns.NewBuilder()
 	// Load link!
+	if err := prog.spendLink(); err != nil {
+		return nil, err
+	}
 	err = lnk.Load(
 		prog.Cfg.Ctx,
 		lnkCtx,
 		nb,
-		prog.Cfg.LinkLoader,
+		prog.budgetedLoader(),
 	)
 	if err != nil {
 		if _, ok := err.(SkipMe); ok {
 			return nil, err
 		}
+		if err := prog.bytesExceeded(); err != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("error traversing node at %q: could not load link %q: %s", prog.Path, lnk, err)
 	}
 	return nb.Build(), nil
 }
 
+// visitedLinks records the (link, selector) pairs a walk has visited,
+// for Config.LinkVisitOnce.
+// A single visitedLinks is shared (by pointer) by all the Progress values of
+// a traversal, like the budget.
+type visitedLinks struct {
+	mu sync.Mutex
+	m  map[string][]selector.Selector // keyed by the link's string form.
+}
+
+// linkVisited returns true if Config.LinkVisitOnce is set and the link has
+// already been visited with the selector.
+func (prog Progress) linkVisited(lnk ipld.Link, s selector.Selector) bool {
+	if prog.visited == nil {
+		return false
+	}
+	prog.visited.mu.Lock()
+	defer prog.visited.mu.Unlock()
+	return prog.visited.seen(lnk, s)
+}
+
+// firstLinkVisit returns false if Config.LinkVisitOnce is set and the link
+// has already been visited with the selector; otherwise it records the visit
+// and returns true.
+func (prog Progress) firstLinkVisit(lnk ipld.Link, s selector.Selector) bool {
+	if prog.visited == nil {
+		return true
+	}
+	prog.visited.mu.Lock()
+	defer prog.visited.mu.Unlock()
+	if prog.visited.seen(lnk, s) {
+		return false
+	}
+	k := lnk.String()
+	prog.visited.m[k] = append(prog.visited.m[k], s)
+	return true
+}
+
+func (vl *visitedLinks) seen(lnk ipld.Link, s selector.Selector) bool {
+	// Selectors aren't necessarily comparable (or hashable) values,
+	//  so compare them deeply; there are rarely many per link.
+	for _, s2 := range vl.m[lnk.String()] {
+		if reflect.DeepEqual(s, s2) {
+			return true
+		}
+	}
+	return false
+}
+
 // WalkTransforming walks a graph of Nodes, deciding which to alter by applying a Selector,
 // and calls the given TransformFn to decide what new node to replace the visited node with.
 // A new Node tree will be returned (the original is unchanged).
@@ -223,7 +564,107 @@ func (prog Progress) loadLink(v ipld.Node, parent ipld.Node) (ipld.Node, error)
 // (literally, builders used to construct any new needed intermediate nodes
 // are chosen by asking the existing nodes about their style).
 //
-// This feature is not yet implemented.
+// Links are followed and loaded as in WalkMatching.
+// If anything beneath a link is replaced, the new content of that block is
+// encoded using the LinkBuilder of the original link, stored using the
+// Config.LinkStorer, and the new link is substituted into the parent;
+// this repeats for every link between the replaced node and the root.
+// (The returned root node itself is not stored; that's up to the caller.)
 func (prog Progress) WalkTransforming(n ipld.Node, s selector.Selector, fn TransformFn) (ipld.Node, error) {
-	panic("TODO")
+	prog.init()
+	return prog.walkTransforming(n, s, fn)
+}
+
+func (prog Progress) walkTransforming(n ipld.Node, s selector.Selector, fn TransformFn) (ipld.Node, error) {
+	if err := prog.spendNode(); err != nil {
+		return nil, err
+	}
+	if s.Decide(n) {
+		progMatch := prog
+		progMatch.Labels = selector.Labels(s, n)
+		n2, err := fn(progMatch, n)
+		if err != nil {
+			return nil, err
+		}
+		if n2 != n {
+			return n2, nil
+		}
+	}
+	nk := n.ReprKind()
+	switch nk {
+	case ipld.ReprKind_Map, ipld.ReprKind_List: // continue
+	default:
+		return n, nil
+	}
+	var replacements map[string]ipld.Node
+	visit := func(ps ipld.PathSegment, v ipld.Node) error {
+		sNext := s.Explore(n, ps)
+		if sNext == nil {
+			return nil
+		}
+		progNext := prog
+		progNext.Path = prog.Path.AppendSegment(ps)
+		if v.ReprKind() == ipld.ReprKind_Link {
+			lnk, _ := v.AsLink()
+			progNext.LastBlock.Path = progNext.Path
+			progNext.LastBlock.Link = lnk
+			loaded, err := progNext.loadLink(v, n)
+			if err != nil {
+				if _, ok := err.(SkipMe); ok {
+					return nil
+				}
+				return err
+			}
+			v2, err := progNext.walkTransforming(loaded, sNext, fn)
+			if err != nil {
+				return err
+			}
+			if v2 == loaded {
+				return nil
+			}
+			v, err = progNext.storeLink(lnk, v, n, v2)
+			if err != nil {
+				return err
+			}
+		} else {
+			v2, err := progNext.walkTransforming(v, sNext, fn)
+			if err != nil {
+				return err
+			}
+			if v2 == v {
+				return nil
+			}
+			v = v2
+		}
+		if replacements == nil {
+			replacements = make(map[string]ipld.Node)
+		}
+		replacements[ps.String()] = v
+		return nil
+	}
+	if attn := s.Interests(); attn == nil {
+		for itr := selector.NewSegmentIterator(n); !itr.Done(); {
+			ps, v, err := itr.Next()
+			if err != nil {
+				return nil, err
+			}
+			if err := visit(ps, v); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for _, ps := range attn {
+			v, err := n.LookupSegment(ps)
+			if err != nil {
+				continue
+			}
+			if err := visit(ps, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if replacements == nil {
+		return n, nil
+	}
+	return rebuild(n, replacements)
 }
@@ -2,8 +2,11 @@ package traversal_test
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"sync"
 	"testing"
+	"time"
 
 	. "github.com/warpfork/go-wish"
 
@@ -161,6 +164,86 @@ func TestWalkMatching(t *testing.T) {
 		Wish(t, err, ShouldEqual, nil)
 		Wish(t, order, ShouldEqual, 6)
 	})
+	t.Run("traverse with labelled matchers should report labels", func(t *testing.T) {
+		isTrue := selector.ConditionHasValue(basicnode.NewBool(true))
+		ss := ssb.ExploreAll(ssb.ExploreUnion(
+			ssb.MatcherWith(&isTrue, "truths"),
+			ssb.MatcherWith(nil, "all"),
+		))
+		s, err := ss.Selector()
+		Require(t, err, ShouldEqual, nil)
+		var order int
+		err = traversal.WalkMatching(middleMapNode, s, func(prog traversal.Progress, n ipld.Node) error {
+			switch order {
+			case 0:
+				Wish(t, prog.Path.String(), ShouldEqual, "foo")
+				Wish(t, prog.Labels, ShouldEqual, []string{"truths", "all"})
+			case 1:
+				Wish(t, prog.Path.String(), ShouldEqual, "bar")
+				Wish(t, prog.Labels, ShouldEqual, []string{"all"})
+			case 2:
+				Wish(t, prog.Path.String(), ShouldEqual, "nested")
+				Wish(t, prog.Labels, ShouldEqual, []string{"all"})
+			}
+			order++
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, order, ShouldEqual, 3)
+	})
+	t.Run("traversing recursively with a stopAt condition should halt at matching nodes", func(t *testing.T) {
+		_, chain0Lnk := encode(fluent.MustBuildMap(basicnode.Style__Map{}, 1, func(na fluent.MapAssembler) {
+			na.AssembleEntry("n").AssignInt(0)
+		}))
+		_, chain1Lnk := encode(fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+			na.AssembleEntry("n").AssignInt(1)
+			na.AssembleEntry("prev").AssignLink(chain0Lnk)
+		}))
+		chain2, _ := encode(fluent.MustBuildMap(basicnode.Style__Map{}, 2, func(na fluent.MapAssembler) {
+			na.AssembleEntry("n").AssignInt(2)
+			na.AssembleEntry("prev").AssignLink(chain1Lnk)
+		}))
+		walk := func(stopAt selector.Condition) (matched []string, loaded []ipld.Link) {
+			s, err := ssb.ExploreRecursiveWithStopAt(selector.RecursionLimitNone(), ssb.ExploreUnion(
+				ssb.Matcher(),
+				ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+					efsb.Insert("prev", ssb.ExploreRecursiveEdge())
+				}),
+			), stopAt).Selector()
+			Require(t, err, ShouldEqual, nil)
+			err = traversal.Progress{
+				Cfg: &traversal.Config{
+					LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+						loaded = append(loaded, lnk)
+						return bytes.NewBuffer(storage[lnk]), nil
+					},
+					LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+						return basicnode.Style__Any{}, nil
+					},
+				},
+			}.WalkMatching(chain2, s, func(prog traversal.Progress, n ipld.Node) error {
+				matched = append(matched, prog.Path.String())
+				return nil
+			})
+			Wish(t, err, ShouldEqual, nil)
+			return
+		}
+		t.Run("stopping at a link should not load it", func(t *testing.T) {
+			matched, loaded := walk(selector.ConditionHasValue(basicnode.NewLink(chain1Lnk)))
+			Wish(t, matched, ShouldEqual, []string{""})
+			Wish(t, loaded, ShouldEqual, []ipld.Link(nil))
+		})
+		t.Run("stopping at a field value should not explore beneath the node", func(t *testing.T) {
+			matched, loaded := walk(selector.ConditionFieldMatches("n", selector.ConditionHasValue(basicnode.NewInt(1))))
+			Wish(t, matched, ShouldEqual, []string{""})
+			Wish(t, loaded, ShouldEqual, []ipld.Link{chain1Lnk})
+		})
+		t.Run("a condition which never holds should not stop anything", func(t *testing.T) {
+			matched, loaded := walk(selector.ConditionHasKind(ipld.ReprKind_Bytes))
+			Wish(t, matched, ShouldEqual, []string{"", "prev", "prev/prev"})
+			Wish(t, loaded, ShouldEqual, []ipld.Link{chain1Lnk, chain0Lnk})
+		})
+	})
 	t.Run("traversing lists should work", func(t *testing.T) {
 		ss := ssb.ExploreRange(0, 3, ssb.Matcher())
 		s, err := ss.Selector()
@@ -264,3 +347,452 @@ func TestWalkMatching(t *testing.T) {
 		Wish(t, order, ShouldEqual, 7)
 	})
 }
+
+func TestWalkTransforming(t *testing.T) {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	t.Run("transform replacing matched fields should rebuild the parent", func(t *testing.T) {
+		ss := ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+			efsb.Insert("foo", ssb.Matcher())
+			efsb.Insert("nested", ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+				efsb.Insert("nonlink", ssb.Matcher())
+			}))
+		})
+		s, err := ss.Selector()
+		Require(t, err, ShouldEqual, nil)
+		var order int
+		n, err := traversal.WalkTransforming(middleMapNode, s, func(prog traversal.Progress, n ipld.Node) (ipld.Node, error) {
+			switch order {
+			case 0:
+				Wish(t, prog.Path.String(), ShouldEqual, "foo")
+			case 1:
+				Wish(t, prog.Path.String(), ShouldEqual, "nested/nonlink")
+			}
+			order++
+			return basicnode.NewString("redacted"), nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, order, ShouldEqual, 2)
+		Wish(t, n, ShouldEqual, fluent.MustBuildMap(basicnode.Style__Map{}, 3, func(na fluent.MapAssembler) {
+			na.AssembleEntry("foo").AssignString("redacted")
+			na.AssembleEntry("bar").AssignBool(false)
+			na.AssembleEntry("nested").CreateMap(2, func(na fluent.MapAssembler) {
+				na.AssembleEntry("alink").AssignLink(leafAlphaLnk)
+				na.AssembleEntry("nonlink").AssignString("redacted")
+			})
+		}))
+		// The original is untouched.
+		foo, _ := middleMapNode.LookupString("foo")
+		Wish(t, foo, ShouldEqual, basicnode.NewBool(true))
+	})
+	t.Run("transform replacing list elements should work", func(t *testing.T) {
+		s, err := ssb.ExploreIndex(1, ssb.Matcher()).Selector()
+		Require(t, err, ShouldEqual, nil)
+		n, err := traversal.WalkTransforming(fluent.MustBuildList(basicnode.Style__List{}, 3, func(na fluent.ListAssembler) {
+			na.AssembleValue().AssignString("alpha")
+			na.AssembleValue().AssignString("beta")
+			na.AssembleValue().AssignString("gamma")
+		}), s, func(prog traversal.Progress, n ipld.Node) (ipld.Node, error) {
+			Wish(t, prog.Path.String(), ShouldEqual, "1")
+			return basicnode.NewString("delta"), nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, n, ShouldEqual, fluent.MustBuildList(basicnode.Style__List{}, 3, func(na fluent.ListAssembler) {
+			na.AssembleValue().AssignString("alpha")
+			na.AssembleValue().AssignString("delta")
+			na.AssembleValue().AssignString("gamma")
+		}))
+	})
+	t.Run("transform returning the same nodes should return the same root", func(t *testing.T) {
+		s, err := ssb.ExploreAll(ssb.Matcher()).Selector()
+		Require(t, err, ShouldEqual, nil)
+		n, err := traversal.WalkTransforming(middleMapNode, s, func(prog traversal.Progress, n ipld.Node) (ipld.Node, error) {
+			return n, nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, n == middleMapNode, ShouldEqual, true)
+	})
+	t.Run("untouched siblings should be shared by identity", func(t *testing.T) {
+		s, err := ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+			efsb.Insert("foo", ssb.Matcher())
+		}).Selector()
+		Require(t, err, ShouldEqual, nil)
+		n, err := traversal.WalkTransforming(middleMapNode, s, func(prog traversal.Progress, n ipld.Node) (ipld.Node, error) {
+			return basicnode.NewBool(false), nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		before, _ := middleMapNode.LookupString("nested")
+		after, _ := n.LookupString("nested")
+		Wish(t, before == after, ShouldEqual, true)
+	})
+	t.Run("transform beneath links should store new blocks up to the root", func(t *testing.T) {
+		s, err := ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+			efsb.Insert("linkedList", ssb.ExploreIndex(2, ssb.Matcher()))
+		}).Selector()
+		Require(t, err, ShouldEqual, nil)
+		var stored []ipld.Link
+		n, err := traversal.Progress{
+			Cfg: &traversal.Config{
+				LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+					return bytes.NewBuffer(storage[lnk]), nil
+				},
+				LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+					return basicnode.Style__Any{}, nil
+				},
+				LinkStorer: func(ipld.LinkContext) (io.Writer, ipld.StoreCommitter, error) {
+					buf := bytes.Buffer{}
+					return &buf, func(lnk ipld.Link) error {
+						storage[lnk] = buf.Bytes()
+						stored = append(stored, lnk)
+						return nil
+					}, nil
+				},
+			},
+		}.WalkTransforming(rootNode, s, func(prog traversal.Progress, n ipld.Node) (ipld.Node, error) {
+			Wish(t, prog.Path.String(), ShouldEqual, "linkedList/2")
+			Wish(t, n, ShouldEqual, basicnode.NewString("beta"))
+			return basicnode.NewString("gamma"), nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		_, expectLeafLnk := encode(basicnode.NewString("gamma"))
+		_, expectListLnk := encode(fluent.MustBuildList(basicnode.Style__List{}, 4, func(na fluent.ListAssembler) {
+			na.AssembleValue().AssignLink(leafAlphaLnk)
+			na.AssembleValue().AssignLink(leafAlphaLnk)
+			na.AssembleValue().AssignLink(expectLeafLnk)
+			na.AssembleValue().AssignLink(leafAlphaLnk)
+		}))
+		Wish(t, stored, ShouldEqual, []ipld.Link{expectLeafLnk, expectListLnk})
+		Wish(t, n, ShouldEqual, fluent.MustBuildMap(basicnode.Style__Map{}, 4, func(na fluent.MapAssembler) {
+			na.AssembleEntry("plain").AssignString("olde string")
+			na.AssembleEntry("linkedString").AssignLink(leafAlphaLnk)
+			na.AssembleEntry("linkedMap").AssignLink(middleMapNodeLnk)
+			na.AssembleEntry("linkedList").AssignLink(expectListLnk)
+		}))
+	})
+}
+
+func TestWalkBudgets(t *testing.T) {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	s, err := ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+		ssb.Matcher(),
+		ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+	)).Selector()
+	Require(t, err, ShouldEqual, nil)
+	walk := func(cfg traversal.Config) (int, error) {
+		cfg.LinkLoader = func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+			return bytes.NewBuffer(storage[lnk]), nil
+		}
+		cfg.LinkTargetNodeStyleChooser = func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+			return basicnode.Style__Any{}, nil
+		}
+		var visits int
+		err := traversal.Progress{Cfg: &cfg}.WalkMatching(rootNode, s, func(prog traversal.Progress, n ipld.Node) error {
+			visits++
+			return nil
+		})
+		return visits, err
+	}
+	t.Run("no budget should walk everything", func(t *testing.T) {
+		visits, err := walk(traversal.Config{})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, visits, ShouldEqual, 14)
+	})
+	t.Run("budgets that are not reached should not interfere", func(t *testing.T) {
+		visits, err := walk(traversal.Config{MaxNodes: 14, MaxLinks: 8, MaxBytes: 1 << 20})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, visits, ShouldEqual, 14)
+	})
+	t.Run("node budget should halt the walk", func(t *testing.T) {
+		visits, err := walk(traversal.Config{MaxNodes: 4})
+		Wish(t, err, ShouldEqual, traversal.ErrBudgetExceeded{Budget: traversal.BudgetKind_Nodes, Limit: 4, Path: ipld.ParsePath("linkedMap/foo")})
+		Wish(t, visits, ShouldEqual, 4)
+	})
+	t.Run("link budget should halt the walk", func(t *testing.T) {
+		visits, err := walk(traversal.Config{MaxLinks: 2})
+		Wish(t, err, ShouldEqual, traversal.ErrBudgetExceeded{Budget: traversal.BudgetKind_Links, Limit: 2, Path: ipld.ParsePath("linkedMap/nested/alink")})
+		Wish(t, visits, ShouldEqual, 7)
+	})
+	t.Run("byte budget should halt the walk", func(t *testing.T) {
+		visits, err := walk(traversal.Config{MaxBytes: int64(len(storage[leafAlphaLnk]))})
+		Wish(t, err, ShouldEqual, traversal.ErrBudgetExceeded{Budget: traversal.BudgetKind_Bytes, Limit: int64(len(storage[leafAlphaLnk])), Path: ipld.ParsePath("linkedMap")})
+		Wish(t, visits, ShouldEqual, 3)
+		Wish(t, err.Error(), ShouldEqual, `traversal budget exceeded at "linkedMap": limit of 7 bytes reached`)
+	})
+}
+
+// loadCounter counts the loads in progress, for tests of prefetching.
+// If it's made to wait, it holds each load until two are in progress at once,
+// so that loads overlap however the goroutines happen to be scheduled.
+// (It gives up waiting after a while; the test then sees only one at a time.)
+type loadCounter struct {
+	mu                  sync.Mutex
+	loading, maxLoading int
+	overlapping         chan struct{} // closed once two loads are in progress, if waiting.
+}
+
+func newLoadCounter(wait bool) *loadCounter {
+	lc := &loadCounter{}
+	if wait {
+		lc.overlapping = make(chan struct{})
+	}
+	return lc
+}
+
+func (lc *loadCounter) start() {
+	lc.mu.Lock()
+	lc.loading++
+	if lc.loading > lc.maxLoading {
+		lc.maxLoading = lc.loading
+		if lc.maxLoading == 2 && lc.overlapping != nil {
+			close(lc.overlapping)
+		}
+	}
+	lc.mu.Unlock()
+	if lc.overlapping != nil {
+		select {
+		case <-lc.overlapping:
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (lc *loadCounter) done() {
+	lc.mu.Lock()
+	lc.loading--
+	lc.mu.Unlock()
+}
+
+func TestWalkPrefetching(t *testing.T) {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	s, err := ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+		ssb.Matcher(),
+		ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+	)).Selector()
+	Require(t, err, ShouldEqual, nil)
+	walk := func(concurrency int) ([]string, int) {
+		lc := newLoadCounter(concurrency > 1)
+		var paths []string
+		err := traversal.Progress{
+			Cfg: &traversal.Config{
+				LinkLoader: func(lnk ipld.Link, lnkCtx ipld.LinkContext) (io.Reader, error) {
+					lc.start()
+					defer lc.done()
+					// Make earlier siblings slower, so that loads tend to finish out of order.
+					idx, _ := lnkCtx.LinkPath.Segments()[0].Index()
+					time.Sleep(time.Duration(5-idx) * time.Millisecond)
+					return bytes.NewBuffer(storage[lnk]), nil
+				},
+				LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+					return basicnode.Style__Any{}, nil
+				},
+				LinkPrefetchConcurrency: concurrency,
+			},
+		}.WalkMatching(middleListNode, s, func(prog traversal.Progress, n ipld.Node) error {
+			paths = append(paths, prog.Path.String())
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		return paths, lc.maxLoading
+	}
+	sequential, maxLoading := walk(0)
+	Wish(t, sequential, ShouldEqual, []string{"", "0", "1", "2", "3"})
+	Wish(t, maxLoading, ShouldEqual, 1)
+	t.Run("prefetching should visit in the same order", func(t *testing.T) {
+		paths, maxLoading := walk(3)
+		Wish(t, paths, ShouldEqual, sequential)
+		Wish(t, maxLoading > 1, ShouldEqual, true)
+		Wish(t, maxLoading <= 3, ShouldEqual, true)
+	})
+	// cfg's loader can fail the load of one link.  Loads are held for a
+	//  moment, so that a walk exceeding its bound would be likely to show it.
+	cfg := func(lc *loadCounter, fail ipld.Link) *traversal.Config {
+		return &traversal.Config{
+			LinkLoader: func(lnk ipld.Link, lnkCtx ipld.LinkContext) (io.Reader, error) {
+				if lnk == fail {
+					return nil, fmt.Errorf("no such block")
+				}
+				lc.start()
+				defer lc.done()
+				time.Sleep(5 * time.Millisecond)
+				return bytes.NewBuffer(storage[lnk]), nil
+			},
+			LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+				return basicnode.Style__Any{}, nil
+			},
+			LinkPrefetchConcurrency: 2,
+		}
+	}
+	t.Run("concurrency should be bounded over the whole walk", func(t *testing.T) {
+		outer, _ := encode(fluent.MustBuildList(basicnode.Style__List{}, 3, func(na fluent.ListAssembler) {
+			na.AssembleValue().AssignLink(middleListNodeLnk)
+			na.AssembleValue().AssignLink(middleListNodeLnk)
+			na.AssembleValue().AssignLink(middleListNodeLnk)
+		}))
+		lc := newLoadCounter(true)
+		var visits int
+		err := traversal.Progress{Cfg: cfg(lc, nil)}.WalkMatching(outer, s, func(prog traversal.Progress, n ipld.Node) error {
+			visits++
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, visits, ShouldEqual, 16)
+		Wish(t, lc.maxLoading, ShouldEqual, 2)
+	})
+	t.Run("an error should wait for the loads in flight", func(t *testing.T) {
+		lc := newLoadCounter(false)
+		err := traversal.Progress{Cfg: cfg(lc, leafAlphaLnk)}.WalkMatching(rootNode, s, func(prog traversal.Progress, n ipld.Node) error {
+			return nil
+		})
+		Wish(t, err == nil, ShouldEqual, false)
+		lc.mu.Lock()
+		Wish(t, lc.loading, ShouldEqual, 0)
+		lc.mu.Unlock()
+	})
+}
+
+func TestWalkSkipping(t *testing.T) {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	s, err := ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+		ssb.Matcher(),
+		ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+	)).Selector()
+	Require(t, err, ShouldEqual, nil)
+	walk := func(n ipld.Node, cfg traversal.Config) []string {
+		if cfg.LinkLoader == nil {
+			cfg.LinkLoader = func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+				return bytes.NewBuffer(storage[lnk]), nil
+			}
+		}
+		cfg.LinkTargetNodeStyleChooser = func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+			return basicnode.Style__Any{}, nil
+		}
+		var paths []string
+		err := traversal.Progress{Cfg: &cfg}.WalkMatching(n, s, func(prog traversal.Progress, n ipld.Node) error {
+			paths = append(paths, prog.Path.String())
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		return paths
+	}
+	t.Run("SkipMe from the loader should skip only that link", func(t *testing.T) {
+		loader := func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+			if lnk == leafAlphaLnk {
+				return nil, traversal.SkipMe{}
+			}
+			return bytes.NewBuffer(storage[lnk]), nil
+		}
+		expect := []string{"", "2"}
+		Wish(t, walk(middleListNode, traversal.Config{LinkLoader: loader}), ShouldEqual, expect)
+		Wish(t, walk(middleListNode, traversal.Config{LinkLoader: loader, LinkPrefetchConcurrency: 2}), ShouldEqual, expect)
+		Wish(t, walk(rootNode, traversal.Config{LinkLoader: loader}), ShouldEqual, []string{
+			"",
+			"plain",
+			"linkedMap",
+			"linkedMap/foo",
+			"linkedMap/bar",
+			"linkedMap/nested",
+			"linkedMap/nested/nonlink",
+			"linkedList",
+			"linkedList/2",
+		})
+	})
+	t.Run("LinkVisitOnce should visit each link once per selector", func(t *testing.T) {
+		expect := []string{
+			"",
+			"plain",
+			"linkedString",
+			"linkedMap",
+			"linkedMap/foo",
+			"linkedMap/bar",
+			"linkedMap/nested",
+			"linkedMap/nested/nonlink",
+			"linkedList",
+			"linkedList/2",
+		}
+		Wish(t, walk(rootNode, traversal.Config{LinkVisitOnce: true}), ShouldEqual, expect)
+		Wish(t, walk(rootNode, traversal.Config{LinkVisitOnce: true, LinkPrefetchConcurrency: 4}), ShouldEqual, expect)
+		Wish(t, len(walk(rootNode, traversal.Config{})), ShouldEqual, 14)
+	})
+	t.Run("LinkVisitOnce should revisit links with a different selector", func(t *testing.T) {
+		s, err := ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+			efsb.Insert("linkedString", ssb.Matcher())
+			efsb.Insert("linkedMap", ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+				efsb.Insert("nested", ssb.ExploreFields(func(efsb builder.ExploreFieldsSpecBuilder) {
+					efsb.Insert("alink", ssb.MatcherWith(nil, "deep"))
+				}))
+			}))
+			efsb.Insert("linkedList", ssb.ExploreIndex(0, ssb.ExploreConditional(selector.ConditionHasKind(ipld.ReprKind_String), ssb.Matcher())))
+		}).Selector()
+		Require(t, err, ShouldEqual, nil)
+		var paths []string
+		err = traversal.Progress{Cfg: &traversal.Config{
+			LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+				return bytes.NewBuffer(storage[lnk]), nil
+			},
+			LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+				return basicnode.Style__Any{}, nil
+			},
+			LinkVisitOnce: true,
+		}}.WalkMatching(rootNode, s, func(prog traversal.Progress, n ipld.Node) error {
+			paths = append(paths, prog.Path.String())
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		Wish(t, paths, ShouldEqual, []string{"linkedString", "linkedMap/nested/alink", "linkedList/0"})
+	})
+}
+
+func TestWalkBreadthFirst(t *testing.T) {
+	ssb := builder.NewSelectorSpecBuilder(basicnode.Style__Any{})
+	isString := selector.ConditionHasKind(ipld.ReprKind_String)
+	s, err := ssb.ExploreRecursive(selector.RecursionLimitNone(), ssb.ExploreUnion(
+		ssb.MatcherWith(&isString, ""),
+		ssb.ExploreAll(ssb.ExploreRecursiveEdge()),
+	)).Selector()
+	Require(t, err, ShouldEqual, nil)
+	walk := func(order traversal.TraversalOrder) []string {
+		var visits []string
+		err := traversal.Progress{
+			Cfg: &traversal.Config{
+				LinkLoader: func(lnk ipld.Link, _ ipld.LinkContext) (io.Reader, error) {
+					return bytes.NewBuffer(storage[lnk]), nil
+				},
+				LinkTargetNodeStyleChooser: func(_ ipld.Link, _ ipld.LinkContext) (ipld.NodeStyle, error) {
+					return basicnode.Style__Any{}, nil
+				},
+				Order: order,
+			},
+		}.WalkAdv(rootNode, s, func(prog traversal.Progress, n ipld.Node, tr traversal.VisitReason) error {
+			visits = append(visits, prog.Path.String()+":"+string(tr)+":"+prog.LastBlock.Path.String())
+			return nil
+		})
+		Wish(t, err, ShouldEqual, nil)
+		return visits
+	}
+	depthFirst := walk(traversal.TraversalOrder_DepthFirst)
+	breadthFirst := walk(traversal.TraversalOrder_BreadthFirst)
+	Wish(t, breadthFirst, ShouldEqual, []string{
+		":x:",
+		"plain:m:",
+		"linkedString:m:linkedString",
+		"linkedMap:x:linkedMap",
+		"linkedList:x:linkedList",
+		"linkedMap/foo:x:linkedMap",
+		"linkedMap/bar:x:linkedMap",
+		"linkedMap/nested:x:linkedMap",
+		"linkedList/0:m:linkedList/0",
+		"linkedList/1:m:linkedList/1",
+		"linkedList/2:m:linkedList/2",
+		"linkedList/3:m:linkedList/3",
+		"linkedMap/nested/alink:m:linkedMap/nested/alink",
+		"linkedMap/nested/nonlink:m:linkedMap",
+	})
+	// The same visits should happen, just in a different order.
+	Wish(t, len(depthFirst), ShouldEqual, len(breadthFirst))
+	seen := map[string]bool{}
+	for _, v := range depthFirst {
+		seen[v] = true
+	}
+	for _, v := range breadthFirst {
+		Wish(t, seen[v], ShouldEqual, true)
+	}
+}